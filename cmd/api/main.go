@@ -1,20 +1,33 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"discountdb-api/internal/cache"
 	"discountdb-api/internal/config"
 	"discountdb-api/internal/database"
+	"discountdb-api/internal/handlers"
 	"discountdb-api/internal/jobs"
+	"discountdb-api/internal/migrations"
+	"discountdb-api/internal/queue"
+	"discountdb-api/internal/repositories"
 	"discountdb-api/internal/routes"
+	"fmt"
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/redis/go-redis/v9"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+// shutdownTimeout is how long each shutdown step may take, first draining requests and then stopping the jobs
+const shutdownTimeout = 30 * time.Second
+
 // @title DiscountDB API
 // @version 1.0
 // @description This is the DiscountDB API documentation
@@ -22,45 +35,101 @@ import (
 // @host api.discountdb.ch
 // @BasePath /api/v1
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the API until SIGINT or SIGTERM, then shuts down gracefully
+func run() error {
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Initialize database
 	db, err := database.NewPostgresDB(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	defer func(db *sql.DB) {
 		err := db.Close()
 		if err != nil {
-			log.Fatalf("Failed to close database connection: %v", err)
+			log.Printf("Failed to close database connection: %v", err)
 		}
 	}(db)
 	log.Printf("Successfully connected to database: %s", cfg.DBName)
 
+	// Apply pending migrations, replicas starting at the same time wait for each other
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+
 	// Initialize redis
 	rdb, err := database.NewRedisClient(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize redis: %v", err)
+		return fmt.Errorf("failed to initialize redis: %w", err)
 	}
 	defer func(rdb *redis.Client) {
 		err := rdb.Close()
 		if err != nil {
-			log.Fatalf("Failed to close redis connection: %v", err)
+			log.Printf("Failed to close redis connection: %v", err)
 		}
 	}(rdb)
 	log.Printf("Successfully connected to redis: %s", cfg.REDISHost)
 
-	// Initialize Cron Jobs
-	scoreUpdate := jobs.NewScoreUpdater(db, 1000, 1*time.Hour)
-	scoreUpdate.Start()
+	// Response cache shared by the handlers and the jobs invalidating it
+	store := cache.New(rdb, cache.Config{
+		TTL:         5 * time.Minute,
+		StaleTTL:    5 * time.Minute,
+		NegativeTTL: time.Minute,
+	})
+
+	// Initialize background jobs, each runs on one replica at a time
+	scoreUpdateSchedule, err := jobs.ParseSchedule(cfg.ScoreUpdateSchedule)
+	if err != nil {
+		return fmt.Errorf("invalid SCORE_UPDATE_SCHEDULE: %w", err)
+	}
+
+	jobRepo := repositories.NewJobRepository(db)
+	scheduler := jobs.NewScheduler(db, jobRepo)
+	// Only coupons crossing a decay step are recomputed, so the updater can run often
+	scheduler.Add(jobs.Job{
+		Name:     "score_update",
+		Schedule: scoreUpdateSchedule,
+		Timeout:  5 * time.Minute,
+		Jitter:   30 * time.Second,
+		Run:      jobs.NewScoreUpdater(repositories.NewScoreRepository(db), store, 1000, 100*time.Millisecond).Run,
+	})
+	scheduler.Add(jobs.Job{
+		Name:     "job_history_cleanup",
+		Schedule: jobs.MustParseSchedule("0 3 * * *"),
+		Timeout:  time.Minute,
+		Jitter:   5 * time.Minute,
+		Run:      jobs.NewJobHistoryCleaner(jobRepo, 30).Run,
+	})
+	// The vote processors of all replicas share the queue
+	voteProcessor := queue.NewVoteProcessor(repositories.NewCouponRepository(db), rdb, store, 100)
+	scheduler.AddWorker("vote_processor", voteProcessor.Run)
+	scheduler.Start()
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: "DiscountDB API v1.0",
+		AppName:      "DiscountDB API v1.0",
+		ErrorHandler: handlers.ErrorHandler,
+
+		// Bodies are streamed so bulk imports can exceed the body limit, which every other
+		// endpoint enforces with middleware.NewBodyLimit
+		StreamRequestBody: true,
 	})
 
 	app.Use(logger.New())
@@ -76,7 +145,39 @@ func main() {
 		AllowOrigins: "*",
 	}))
 
-	routes.SetupRoutes(app, db, rdb)
+	routes.SetupRoutes(app, cfg, db, rdb, store, scheduler)
+
+	// Serve until the process is asked to stop
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- app.Listen(":3000")
+	}()
+
+	var runErr error
+	select {
+	case <-signals.Done():
+		log.Printf("Shutting down")
+	case err := <-serverErr:
+		runErr = fmt.Errorf("server failed: %w", err)
+	}
+	// A second signal stops the process immediately
+	stop()
+
+	// Stop accepting requests and let the running ones finish, they may still need redis and the database
+	if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+		log.Printf("Failed to finish all requests: %v", err)
+	}
+
+	// Cancel the jobs and workers, the vote processor finishes its current batch
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := scheduler.Stop(ctx); err != nil {
+		log.Printf("Failed to stop background jobs: %v", err)
+	}
 
-	log.Fatal(app.Listen(":3000"))
+	// The deferred functions close redis and then the database
+	return runErr
 }
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/config"
+	"discountdb-api/internal/database"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: apikey <command>
+
+Commands:
+  create <name> <scopes> [quotas]   create a key, scopes and quotas are comma separated,
+                                    e.g. read,vote default=1000,vote=100
+  list                              list all keys
+  revoke <id>                       revoke a key
+  quota <id> [quotas]               replace the quotas of a key, none resets them to the defaults
+  trust <id> <true|false>           set whether coupons submitted with a key skip moderation
+
+Scopes: read, submit, vote, bulk, admin (includes all others)
+Quotas: requests per window of the default, vote, create and export rate limits`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func(db *sql.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Fatalf("Failed to close database connection: %v", err)
+		}
+	}(db)
+
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "create":
+		if len(os.Args) < 4 {
+			fmt.Println(usage)
+			os.Exit(2)
+		}
+		scopes, err := parseScopes(os.Args[3])
+		if err != nil {
+			log.Fatal(err)
+		}
+		var quotas map[string]int
+		if len(os.Args) > 4 {
+			if quotas, err = parseQuotas(os.Args[4]); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		secret, _, err := repositories.NewAPIKeySecret()
+		if err != nil {
+			log.Fatalf("Failed to generate API key: %v", err)
+		}
+		key := &models.APIKey{Name: os.Args[2], Scopes: scopes, Quotas: quotas}
+		if err := apiKeyRepo.Create(ctx, key, secret); err != nil {
+			log.Fatalf("Failed to create API key: %v", err)
+		}
+
+		// The key is only shown once, the database only holds its hash
+		log.Printf("Created API key %d (%s)", key.ID, key.Name)
+		fmt.Println(secret)
+
+	case "list":
+		keys, err := apiKeyRepo.List(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list API keys: %v", err)
+		}
+		for _, key := range keys {
+			state := "active"
+			if key.Trusted {
+				state = "trusted"
+			}
+			if key.RevokedAt != nil {
+				state = "revoked " + key.RevokedAt.Format(time.RFC3339)
+			} else if key.LastUsedAt != nil {
+				state += ", used " + key.LastUsedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-6d %-12s %-24s %-30s %-30s %s\n",
+				key.ID, key.Prefix, key.Name, strings.Join(key.Scopes, ","), formatQuotas(key.Quotas), state)
+		}
+
+	case "revoke":
+		if len(os.Args) < 3 {
+			fmt.Println(usage)
+			os.Exit(2)
+		}
+		id := parseID(os.Args[2])
+		if err := apiKeyRepo.Revoke(ctx, id); err != nil {
+			log.Fatalf("Failed to revoke API key: %v", err)
+		}
+		invalidate(cfg, id)
+		log.Printf("Revoked API key %d", id)
+
+	case "quota":
+		if len(os.Args) < 3 {
+			fmt.Println(usage)
+			os.Exit(2)
+		}
+		id := parseID(os.Args[2])
+		var quotas map[string]int
+		if len(os.Args) > 3 {
+			if quotas, err = parseQuotas(os.Args[3]); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := apiKeyRepo.SetQuotas(ctx, id, quotas); err != nil {
+			log.Fatalf("Failed to set quotas: %v", err)
+		}
+		invalidate(cfg, id)
+		log.Printf("Updated quotas of API key %d", id)
+
+	case "trust":
+		if len(os.Args) < 4 {
+			fmt.Println(usage)
+			os.Exit(2)
+		}
+		id := parseID(os.Args[2])
+		trusted, err := strconv.ParseBool(os.Args[3])
+		if err != nil {
+			log.Fatalf("Invalid trust value: %s", os.Args[3])
+		}
+		if err := apiKeyRepo.SetTrusted(ctx, id, trusted); err != nil {
+			log.Fatalf("Failed to set trust: %v", err)
+		}
+		invalidate(cfg, id)
+		log.Printf("Set trust of API key %d to %t", id, trusted)
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
+
+// invalidate removes the cached lookups of a key, so the API sees the change immediately
+func invalidate(cfg *config.Config, id int64) {
+	rdb, err := database.NewRedisClient(cfg)
+	if err != nil {
+		log.Printf("Failed to initialize redis, the change applies once the cached key expires: %v", err)
+		return
+	}
+	defer func(rdb *redis.Client) {
+		err := rdb.Close()
+		if err != nil {
+			log.Printf("Failed to close redis connection: %v", err)
+		}
+	}(rdb)
+
+	cache.New(rdb, cache.Config{}).Invalidate(context.Background(), cache.APIKeyTag(id))
+}
+
+func parseID(s string) int64 {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		log.Fatalf("Invalid API key id: %s", s)
+	}
+	return id
+}
+
+func parseScopes(s string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, known := range models.Scopes {
+			valid = valid || scope == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+func parseQuotas(s string) (map[string]int, error) {
+	quotas := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		max, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || strings.TrimSpace(name) == "" || err != nil || max < 1 {
+			return nil, fmt.Errorf("invalid quota: %s", pair)
+		}
+		quotas[strings.TrimSpace(name)] = max
+	}
+	return quotas, nil
+}
+
+func formatQuotas(quotas map[string]int) string {
+	if len(quotas) == 0 {
+		return "-"
+	}
+	var parts []string
+	for name, max := range quotas {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, max))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"discountdb-api/internal/config"
+	"discountdb-api/internal/database"
+	"discountdb-api/internal/export"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const usage = `Usage: export [flags]
+
+Writes all coupons to a dump file. For an incremental sync pass the latest
+update time logged by the previous run as -updated-since.
+
+Flags:`
+
+func main() {
+	format := flag.String("format", export.FormatNDJSON, "output format: ndjson, csv or columnar")
+	output := flag.String("o", "-", "output file, - for stdout")
+	updatedSince := flag.String("updated-since", "", "only export coupons changed at or after this time (RFC3339)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var params repositories.ExportParams
+	if *updatedSince != "" {
+		t, err := time.Parse(time.RFC3339, *updatedSince)
+		if err != nil {
+			log.Fatalf("Invalid -updated-since: %v", err)
+		}
+		params.UpdatedSince = &t
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func(db *sql.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Fatalf("Failed to close database connection: %v", err)
+		}
+	}(db)
+
+	couponRepo := repositories.NewCouponRepository(db)
+
+	if *output == "-" {
+		if err := dump(os.Stdout, couponRepo, *format, params); err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		return
+	}
+
+	// Write to a temporary file first, so an interrupted export never replaces a previous dump
+	file, err := os.CreateTemp(filepath.Dir(*output), filepath.Base(*output)+".*.tmp")
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	err = dump(file, couponRepo, *format, params)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+	if err := os.Rename(file.Name(), *output); err != nil {
+		log.Fatalf("Failed to write output file: %v", err)
+	}
+}
+
+// dump writes all coupons selected by params and the removals since params.UpdatedSince to w,
+// and logs the latest update time for the next incremental sync
+func dump(w io.Writer, couponRepo *repositories.CouponRepository, format string, params repositories.ExportParams) error {
+	buffered := bufio.NewWriter(w)
+	writer, err := export.NewWriter(buffered, format)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	var latest time.Time
+	err = couponRepo.Export(context.Background(), params, func(coupon *models.Coupon) error {
+		count++
+		if coupon.UpdatedAt.After(latest) {
+			latest = coupon.UpdatedAt
+		}
+		return writer.Write(coupon)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only incremental exports need to pass on removals
+	removed := 0
+	if params.UpdatedSince != nil {
+		err = couponRepo.ExportRemovals(context.Background(), *params.UpdatedSince, func(removal *models.CouponRemoval) error {
+			removed++
+			if removal.RemovedAt.After(latest) {
+				latest = removal.RemovedAt
+			}
+			return writer.WriteRemoval(removal)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+
+	if count == 0 && removed == 0 {
+		log.Printf("No coupons to export")
+		return nil
+	}
+	log.Printf("Exported %d coupons and %d removals, latest update at %s", count, removed, latest.Format(time.RFC3339Nano))
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/config"
+	"discountdb-api/internal/database"
+	"discountdb-api/internal/jobs"
+	"discountdb-api/internal/repositories"
+	"flag"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"time"
+)
+
+const usage = `Usage: rescore [flags]
+
+Recomputes the score of every coupon with the current score settings,
+e.g. after switching the vote model. The API keeps serving the old scores
+of coupons that were not recomputed yet.
+
+Flags:`
+
+func main() {
+	batchSize := flag.Int("batch-size", 1000, "number of coupons recomputed per transaction")
+	pause := flag.Duration("pause", 100*time.Millisecond, "pause between batches to reduce database load")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *batchSize < 1 {
+		log.Fatalf("Invalid -batch-size: %d", *batchSize)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func(db *sql.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Fatalf("Failed to close database connection: %v", err)
+		}
+	}(db)
+
+	// The cached entries of every recomputed batch are invalidated, so the API serves the new scores immediately
+	var store *cache.Store
+	rdb, err := database.NewRedisClient(cfg)
+	if err != nil {
+		log.Printf("Failed to initialize redis, the new scores are served once the cache expires: %v", err)
+	} else {
+		defer func(rdb *redis.Client) {
+			err := rdb.Close()
+			if err != nil {
+				log.Printf("Failed to close redis connection: %v", err)
+			}
+		}(rdb)
+		store = cache.New(rdb, cache.Config{})
+	}
+
+	scoreRepo := repositories.NewScoreRepository(db)
+	ctx := context.Background()
+
+	var afterID int64
+	total := 0
+	start := time.Now()
+	for {
+		coupons, err := scoreRepo.RecomputeBatch(ctx, afterID, *batchSize)
+		if err != nil {
+			log.Fatalf("Failed to recompute scores after coupon %d: %v", afterID, err)
+		}
+		if len(coupons) == 0 {
+			break
+		}
+		store.Invalidate(ctx, jobs.RescoredTags(coupons)...)
+
+		for _, coupon := range coupons {
+			afterID = max(afterID, coupon.ID)
+		}
+		total += len(coupons)
+		log.Printf("Recomputed %d coupons, up to id %d", total, afterID)
+
+		time.Sleep(*pause)
+	}
+
+	log.Printf("Recomputed the scores of %d coupons in %s", total, time.Since(start).Round(time.Second))
+}
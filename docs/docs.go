@@ -16,35 +16,37 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/coupons": {
+        "/admin/coupons/{id}/approve": {
             "post": {
-                "description": "Create a new coupon",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Make a coupon public. Requires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "coupons"
+                    "admin"
                 ],
-                "summary": "Create a new coupon",
+                "summary": "Approve a coupon",
                 "parameters": [
                     {
-                        "description": "CouponCreateRequest object",
-                        "name": "coupon",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CouponCreateRequest"
-                        }
+                        "type": "string",
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CouponCreateResponse"
+                            "$ref": "#/definitions/models.Success"
                         }
                     },
                     "400": {
@@ -53,34 +55,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Invalid API key",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/coupons/categories": {
-            "get": {
-                "description": "Retrieve a list of all categories",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "categories"
-                ],
-                "summary": "Get all categories",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
-                            "$ref": "#/definitions/models.CategoriesResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Coupon not found",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -88,51 +76,71 @@ const docTemplate = `{
                 }
             }
         },
-        "/coupons/merchants": {
-            "get": {
-                "description": "Retrieve a list of all merchants",
+        "/admin/coupons/{id}/reject": {
+            "post": {
+                "description": "Reject a coupon with a reason, it is no longer public. Requires an API key with the admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "merchants"
+                    "admin"
+                ],
+                "summary": "Reject a coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason of the rejection",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerationRejectRequest"
+                        }
+                    }
                 ],
-                "summary": "Get all merchants",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.MerchantResponse"
+                            "$ref": "#/definitions/models.Success"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/coupons/regions": {
-            "get": {
-                "description": "Retrieve a list of all regions",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "regions"
-                ],
-                "summary": "Get all regions",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "401": {
+                        "description": "Invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/models.RegionResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -140,53 +148,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/coupons/search": {
+        "/admin/jobs": {
             "get": {
-                "description": "Retrieve a list of coupons with optional search, sorting, and pagination",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List the scheduled jobs with their latest runs, newest first, and the workers of the replica answering the request.\nRequires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "coupons"
+                    "admin"
                 ],
-                "summary": "Get coupons with filtering and pagination",
+                "summary": "List background jobs",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Search query string",
-                        "name": "q",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "newest",
-                            "oldest",
-                            "high_score",
-                            "low_score"
-                        ],
-                        "type": "string",
-                        "default": "newest",
-                        "description": "Sort order (newest, oldest, high_score, low_score)",
-                        "name": "sort_by",
-                        "in": "query"
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
                     },
                     {
+                        "maximum": 100,
                         "minimum": 1,
                         "type": "integer",
                         "default": 10,
-                        "description": "Number of items per page",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "minimum": 0,
-                        "type": "integer",
-                        "default": 0,
-                        "description": "Number of items to skip",
-                        "name": "offset",
+                        "description": "Number of runs to list per job",
+                        "name": "runs",
                         "in": "query"
                     }
                 ],
@@ -194,7 +180,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CouponsSearchResponse"
+                            "$ref": "#/definitions/models.JobsResponse"
                         }
                     },
                     "400": {
@@ -203,34 +189,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Invalid API key",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/coupons/tags": {
-            "get": {
-                "description": "Retrieve a list of all tags",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "tags"
-                ],
-                "summary": "Get all tags",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.TagResponse"
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -238,28 +204,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/coupons/vote/{dir}/{id}": {
+        "/admin/jobs/{name}/run": {
             "post": {
-                "description": "Vote on a coupon by ID",
+                "description": "Start a run of a scheduled job now, it runs in the background. Requires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "votes"
+                    "admin"
                 ],
-                "summary": "Vote on a coupon",
+                "summary": "Run a background job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Vote direction (up or down)",
-                        "name": "dir",
-                        "in": "path",
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Coupon ID",
-                        "name": "id",
+                        "description": "Job name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     }
@@ -268,11 +234,29 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Success"
+                            "$ref": "#/definitions/models.JobRun"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Job is already running",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -280,9 +264,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/coupons/{id}": {
-            "get": {
-                "description": "Retrieve a single coupon by its ID",
+        "/admin/merchants/hide": {
+            "post": {
+                "description": "Hide every pending or approved coupon of a merchant domain. Requires an API key with the admin scope.",
                 "consumes": [
                     "application/json"
                 ],
@@ -290,62 +274,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "coupons"
+                    "admin"
                 ],
-                "summary": "Get coupon by ID",
+                "summary": "Hide all coupons of a merchant",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Coupon ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "Merchant domain and reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModerationHideMerchantRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Coupon"
+                            "$ref": "#/definitions/models.ModerationHideMerchantResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Invalid API key",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Get API health status",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check endpoint",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.HealthCheckResponse"
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -353,36 +323,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/syrup/coupons": {
+        "/admin/moderation/log": {
             "get": {
-                "description": "Returns a paginated list of coupons for a specific domain",
+                "description": "List the moderation log, newest first. Requires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "syrup"
+                    "admin"
                 ],
-                "summary": "List Coupons",
+                "summary": "List moderation decisions",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Optional API key for authentication",
-                        "name": "X-Syrup-API-Key",
-                        "in": "header"
-                    },
-                    {
-                        "type": "string",
-                        "description": "The website domain to fetch coupons for",
-                        "name": "domain",
-                        "in": "query",
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "maximum": 100,
+                        "type": "integer",
+                        "description": "Only the decisions about this coupon",
+                        "name": "coupon_id",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 200,
                         "minimum": 1,
                         "type": "integer",
-                        "default": 20,
-                        "description": "Maximum number of coupons to return",
+                        "default": 50,
+                        "description": "Maximum number of entries to return",
                         "name": "limit",
                         "in": "query"
                     },
@@ -390,312 +360,1542 @@ const docTemplate = `{
                         "minimum": 0,
                         "type": "integer",
                         "default": 0,
-                        "description": "Number of coupons to skip",
+                        "description": "Number of entries to skip",
                         "name": "offset",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/syrup.CouponList"
-                        },
-                        "headers": {
-                            "X-RateLimit-Limit": {
-                                "type": "string",
-                                "description": "The maximum number of requests allowed per time window"
-                            },
-                            "X-RateLimit-Remaining": {
-                                "type": "string",
-                                "description": "The number of requests remaining in the time window"
-                            },
-                            "X-RateLimit-Reset": {
-                                "type": "string",
-                                "description": "The time when the rate limit window resets (Unix timestamp)"
-                            }
+                            "$ref": "#/definitions/models.ModerationLogResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        }
-                    },
-                    "429": {
-                        "description": "Too Many Requests",
+                        "description": "Invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        },
-                        "headers": {
-                            "X-RateLimit-RetryAfter": {
-                                "type": "integer",
-                                "description": "Time to wait before retrying (seconds)"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/syrup/coupons/invalid/{id}": {
-            "post": {
-                "description": "Report that a coupon code failed to work",
+        "/admin/moderation/queue": {
+            "get": {
+                "description": "List coupons by moderation state, oldest first. Requires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "syrup"
+                    "admin"
                 ],
-                "summary": "Report Invalid Coupon",
+                "summary": "List the moderation queue",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Optional API key for authentication",
-                        "name": "X-Syrup-API-Key",
-                        "in": "header"
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
                     },
                     {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected",
+                            "hidden"
+                        ],
                         "type": "string",
-                        "description": "The ID of the coupon",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": "pending",
+                        "description": "Moderation state",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of coupons to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of coupons to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/syrup.Success"
-                        },
-                        "headers": {
-                            "X-RateLimit-Limit": {
-                                "type": "string",
-                                "description": "The maximum number of requests allowed per time window"
-                            },
-                            "X-RateLimit-Remaining": {
-                                "type": "string",
-                                "description": "The number of requests remaining in the time window"
-                            },
-                            "X-RateLimit-Reset": {
-                                "type": "string",
-                                "description": "The time when the rate limit window resets (Unix timestamp)"
-                            }
+                            "$ref": "#/definitions/models.ModerationQueueResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        }
-                    },
-                    "429": {
-                        "description": "Too Many Requests",
+                        "description": "Invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        },
-                        "headers": {
-                            "X-RateLimit-RetryAfter": {
-                                "type": "integer",
-                                "description": "Time to wait before retrying (seconds)"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/syrup/coupons/valid/{id}": {
-            "post": {
-                "description": "Report that a coupon code was successfully used",
+        "/admin/reports": {
+            "get": {
+                "description": "List the reported coupons with their number of reports per reason, most reported first. Requires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "syrup"
+                    "admin"
                 ],
-                "summary": "Report Valid Coupon",
+                "summary": "List reported coupons",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Optional API key for authentication",
-                        "name": "X-Syrup-API-Key",
-                        "in": "header"
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "The ID of the coupon",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of coupons to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of coupons to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/syrup.Success"
-                        },
-                        "headers": {
-                            "X-RateLimit-Limit": {
-                                "type": "string",
-                                "description": "The maximum number of requests allowed per time window"
-                            },
-                            "X-RateLimit-Remaining": {
-                                "type": "string",
-                                "description": "The number of requests remaining in the time window"
-                            },
-                            "X-RateLimit-Reset": {
-                                "type": "string",
-                                "description": "The time when the rate limit window resets (Unix timestamp)"
-                            }
+                            "$ref": "#/definitions/models.ReportStatsResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        }
-                    },
-                    "429": {
-                        "description": "Too Many Requests",
+                        "description": "Invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        },
-                        "headers": {
-                            "X-RateLimit-RetryAfter": {
-                                "type": "integer",
-                                "description": "Time to wait before retrying (seconds)"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/syrup/merchants": {
+        "/admin/score-settings": {
             "get": {
-                "description": "Returns a list of all merchants and their domains",
+                "description": "List the versions of the ranking parameters, newest first. The newest version is in use. Requires an API key with the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "syrup"
+                    "admin"
                 ],
-                "summary": "List all Merchants",
+                "summary": "List score settings versions",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Optional API key for authentication",
-                        "name": "X-Syrup-API-Key",
-                        "in": "header"
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of versions to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of versions to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/syrup.MerchantList"
-                        },
-                        "headers": {
-                            "X-RateLimit-Limit": {
-                                "type": "string",
-                                "description": "The maximum number of requests allowed per time window"
-                            },
-                            "X-RateLimit-Remaining": {
-                                "type": "string",
-                                "description": "The number of requests remaining in the time window"
-                            },
-                            "X-RateLimit-Reset": {
-                                "type": "string",
-                                "description": "The time when the rate limit window resets (Unix timestamp)"
-                            }
+                            "$ref": "#/definitions/models.ScoreSettingsListResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        }
-                    },
-                    "429": {
-                        "description": "Too Many Requests",
+                        "description": "Invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
-                        },
-                        "headers": {
-                            "X-RateLimit-RetryAfter": {
-                                "type": "integer",
-                                "description": "Time to wait before retrying (seconds)"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "API key lacks the admin scope",
                         "schema": {
-                            "$ref": "#/definitions/syrup.ErrorResponse"
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/syrup/version": {
-            "get": {
-                "description": "Returns information about the API implementation",
+            },
+            "post": {
+                "description": "Store new ranking parameters as the next version, which is used from now on.\nAll scores are recomputed by the next score update. To roll back, post an older version again.\nRequires an API key with the admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "syrup"
+                    "admin"
                 ],
-                "summary": "Get API Version",
+                "summary": "Create a score settings version",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Optional API key for authentication",
-                        "name": "X-Syrup-API-Key",
-                        "in": "header"
-                    }
+                        "description": "API key with the admin scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Ranking parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScoreSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScoreSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons": {
+            "post": {
+                "description": "Create a new coupon. The response contains a secret edit token required to update or delete it later. New coupons are only public once approved by a moderator, unless submitted with a trusted API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Create a new coupon",
+                "parameters": [
+                    {
+                        "description": "CouponCreateRequest object",
+                        "name": "coupon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CouponCreateRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "reject",
+                            "merge"
+                        ],
+                        "type": "string",
+                        "default": "reject",
+                        "description": "What to do if the code already exists for the merchant domain: reject with 409 or merge tags, categories, regions and dates into the existing coupon, which then waits for moderation again unless the API key is trusted. Merging needs a trusted API key or the edit token of the existing coupon, otherwise it is rejected with 409 as well. Rejected and hidden coupons are ignored",
+                        "name": "on_duplicate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Edit token of the existing coupon, allows on_duplicate=merge without a trusted API key",
+                        "name": "X-Edit-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CouponCreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Coupon already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.DuplicateCouponResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/bulk": {
+            "post": {
+                "description": "Import coupons from a JSON array, NDJSON or CSV of up to 32 MB and 10000 rows. Every row is validated like a single submission and reported separately. Rows whose code already exists for the merchant domain are skipped as duplicates. All created coupons are written in one transaction and wait for moderation unless the API key is trusted.",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Import coupons in bulk",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key with the bulk scope",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "ndjson",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Input format, detected from the Content-Type header if omitted",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV column mapping as comma separated field=column pairs, unmapped fields are read from the column of the same name",
+                        "name": "mapping",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "|",
+                        "description": "Separator of the categories, tags and regions in CSV cells",
+                        "name": "list_separator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Only validate the rows and report duplicates, nothing is written",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key lacks the bulk scope",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body exceeds 32 MB",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/categories": {
+            "get": {
+                "description": "Retrieve a list of all categories",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get all categories",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CategoriesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/export": {
+            "get": {
+                "description": "Stream the full dataset as NDJSON, CSV or columnar JSON, ordered by the time of the last change. Pass the largest updated_at or removed_at of the previous export as updated_since to only receive coupons changed since then, followed by the coupons deleted or un-published since then: NDJSON lines of the form {\"removed\":{\"id\",\"removed_at\",\"reason\"}}, CSV rows with only id, updated_at and the reason in the last column \"removed\", or the \"removed\" groups of the columnar format. The CSV lists are separated by \"|\", like the default of the bulk import. The columnar format holds a Parquet schema and row groups of 1000 rows with one array per column, timestamps are microseconds since the Unix epoch.",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Export all coupons",
+                "parameters": [
+                    {
+                        "enum": [
+                            "ndjson",
+                            "csv",
+                            "columnar"
+                        ],
+                        "type": "string",
+                        "default": "ndjson",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only coupons changed at or after this time (RFC3339 or YYYY-MM-DD)",
+                        "name": "updated_since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exported coupons",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/merchants": {
+            "get": {
+                "description": "Retrieve a list of all merchants",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "merchants"
+                ],
+                "summary": "Get all merchants",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MerchantResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/regions": {
+            "get": {
+                "description": "Retrieve a list of all regions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "regions"
+                ],
+                "summary": "Get all regions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RegionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/search": {
+            "get": {
+                "description": "Retrieve a list of coupons with optional search, filtering, sorting, and pagination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Get coupons with filtering and pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Full-text search query, supports quoted phrases, prefix* and -excluded terms",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "high_score",
+                            "low_score",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "default": "newest",
+                        "description": "Sort order (newest, oldest, high_score, low_score, relevance)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor of the previous page, replaces offset",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Whether to count all matching coupons, disable for infinite scrolling",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by merchant name (repeatable, any value matches)",
+                        "name": "merchant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by category (repeatable)",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by tag (repeatable)",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by region (repeatable)",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "PERCENTAGE_OFF",
+                                "FIXED_AMOUNT",
+                                "BOGO",
+                                "FREE_SHIPPING"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by discount type (repeatable, any value matches)",
+                        "name": "discount_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "online",
+                                "in_store",
+                                "both"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filter by store type (repeatable, any value matches)",
+                        "name": "store_type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Whether category, tag and region filters require any or all of the given values",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "true",
+                            "false",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "true",
+                        "description": "Validity filter: only active, only expired/not yet started, or all coupons",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only coupons with an end date before this time (RFC 3339 or YYYY-MM-DD)",
+                        "name": "expires_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only coupons still valid after this time, including coupons without an end date (RFC 3339 or YYYY-MM-DD)",
+                        "name": "expires_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CouponsSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/tags": {
+            "get": {
+                "description": "Retrieve a list of all tags",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get all tags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TagResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/vote/{dir}/{id}": {
+            "post": {
+                "description": "Vote on a coupon by ID. Each voter (IP and optional X-Voter-Token) has one vote per coupon, voting again in the other direction changes it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "Vote on a coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vote direction (up or down)",
+                        "name": "dir",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional client token identifying the voter",
+                        "name": "X-Voter-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Success"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/vote/{id}": {
+            "delete": {
+                "description": "Withdraw the caller's vote on a coupon",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "votes"
+                ],
+                "summary": "Withdraw a vote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional client token identifying the voter, must match the one used to vote",
+                        "name": "X-Voter-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Success"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/{id}": {
+            "get": {
+                "description": "Retrieve a single coupon by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Get coupon by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Coupon"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a coupon, requires the edit token returned when the coupon was created",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Delete a coupon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Edit token returned when the coupon was created",
+                        "name": "X-Edit-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Success"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid edit token for an approved coupon",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found, or not approved and the edit token is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update the given fields of a coupon, requires the edit token returned when the coupon was created. Dates are removed by sending null. The changed coupon is reviewed again unless submitted with a trusted API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Update a coupon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Edit token returned when the coupon was created",
+                        "name": "X-Edit-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "coupon",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CouponUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Coupon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found or invalid edit token",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/{id}/report": {
+            "post": {
+                "description": "Report an expired, misattributed, spam or offensive coupon. Each reporter (API key, or else IP) has one report per coupon, reporting again replaces it. Coupons reported by too many reporters since they were last moderated are hidden or sent back to moderation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Report a coupon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and optional comment",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Success"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/coupons/{id}/score": {
+            "get": {
+                "description": "Break the score of a coupon down into its vote, discount and freshness parts under the current score settings.\nThe stored score used for sorting is recomputed periodically, so it can lag behind the total.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "coupons"
+                ],
+                "summary": "Explain a coupon's score",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Coupon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScoreExplanation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Coupon not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get API health status. The status is degraded while the rate limiters cannot reach Redis,\nthe rate limiter mode is then the configured failure policy instead of redis.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthCheckResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/syrup/coupons": {
+            "get": {
+                "description": "Returns a paginated list of coupons for a specific domain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "syrup"
+                ],
+                "summary": "List Coupons",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Optional API key for authentication",
+                        "name": "X-Syrup-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The website domain to fetch coupons for",
+                        "name": "domain",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of coupons to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of coupons to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.CouponList"
+                        },
+                        "headers": {
+                            "X-RateLimit-Limit": {
+                                "type": "string",
+                                "description": "The maximum number of requests allowed per time window"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "string",
+                                "description": "The number of requests remaining in the time window"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "string",
+                                "description": "The time when the rate limit window resets (Unix timestamp)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        },
+                        "headers": {
+                            "X-RateLimit-RetryAfter": {
+                                "type": "integer",
+                                "description": "Time to wait before retrying (seconds)"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/syrup/coupons/invalid/{id}": {
+            "post": {
+                "description": "Report that a coupon code failed to work",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "syrup"
+                ],
+                "summary": "Report Invalid Coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Optional API key for authentication",
+                        "name": "X-Syrup-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The ID of the coupon",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.Success"
+                        },
+                        "headers": {
+                            "X-RateLimit-Limit": {
+                                "type": "string",
+                                "description": "The maximum number of requests allowed per time window"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "string",
+                                "description": "The number of requests remaining in the time window"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "string",
+                                "description": "The time when the rate limit window resets (Unix timestamp)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        },
+                        "headers": {
+                            "X-RateLimit-RetryAfter": {
+                                "type": "integer",
+                                "description": "Time to wait before retrying (seconds)"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/syrup/coupons/valid/{id}": {
+            "post": {
+                "description": "Report that a coupon code was successfully used",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "syrup"
+                ],
+                "summary": "Report Valid Coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Optional API key for authentication",
+                        "name": "X-Syrup-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The ID of the coupon",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.Success"
+                        },
+                        "headers": {
+                            "X-RateLimit-Limit": {
+                                "type": "string",
+                                "description": "The maximum number of requests allowed per time window"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "string",
+                                "description": "The number of requests remaining in the time window"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "string",
+                                "description": "The time when the rate limit window resets (Unix timestamp)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        },
+                        "headers": {
+                            "X-RateLimit-RetryAfter": {
+                                "type": "integer",
+                                "description": "Time to wait before retrying (seconds)"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/syrup/merchants": {
+            "get": {
+                "description": "Returns a list of all merchants and their domains",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "syrup"
+                ],
+                "summary": "List all Merchants",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Optional API key for authentication",
+                        "name": "X-Syrup-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.MerchantList"
+                        },
+                        "headers": {
+                            "X-RateLimit-Limit": {
+                                "type": "string",
+                                "description": "The maximum number of requests allowed per time window"
+                            },
+                            "X-RateLimit-Remaining": {
+                                "type": "string",
+                                "description": "The number of requests remaining in the time window"
+                            },
+                            "X-RateLimit-Reset": {
+                                "type": "string",
+                                "description": "The time when the rate limit window resets (Unix timestamp)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        },
+                        "headers": {
+                            "X-RateLimit-RetryAfter": {
+                                "type": "integer",
+                                "description": "Time to wait before retrying (seconds)"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/syrup.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/syrup/version": {
+            "get": {
+                "description": "Returns information about the API implementation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "syrup"
+                ],
+                "summary": "Get API Version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Optional API key for authentication",
+                        "name": "X-Syrup-API-Key",
+                        "in": "header"
+                    }
                 ],
                 "responses": {
                     "200": {
@@ -750,29 +1950,335 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.CategoriesResponse": {
+        }
+    },
+    "definitions": {
+        "models.BulkImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "duplicates": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "invalid": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BulkImportRowResult"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "valid": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "models.BulkImportRowResult": {
+            "type": "object",
+            "properties": {
+                "duplicate_of_row": {
+                    "description": "duplicate of an earlier row of the same import",
+                    "type": "integer",
+                    "example": 3
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Coupon code is required"
+                },
+                "existing_id": {
+                    "description": "duplicate of a coupon already in the database",
+                    "type": "integer",
+                    "example": 17
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "row": {
+                    "description": "1-based position of the row in the input, not counting a CSV header",
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "valid",
+                        "invalid",
+                        "duplicate"
+                    ],
+                    "example": "created"
+                }
+            }
+        },
+        "models.CategoriesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.Coupon": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "description": "Metadata",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount_type": {
+                    "$ref": "#/definitions/models.DiscountType"
+                },
+                "discount_value": {
+                    "type": "number"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Required Information",
+                    "type": "integer"
+                },
+                "is_active": {
+                    "description": "derived from start_date and end_date",
+                    "type": "boolean"
+                },
+                "maximum_discount_amount": {
+                    "type": "number"
+                },
+                "merchant_name": {
+                    "type": "string"
+                },
+                "merchant_url": {
+                    "type": "string"
+                },
+                "minimum_purchase_amount": {
+                    "type": "number"
+                },
+                "moderation_reason": {
+                    "type": "string"
+                },
+                "moderation_status": {
+                    "description": "Moderation state, not part of search results, which only contain approved coupons",
+                    "type": "string"
+                },
+                "regions": {
+                    "description": "countries/regions where valid",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "description": "Score calculated by db",
+                    "type": "number"
+                },
+                "start_date": {
+                    "description": "Optional Validity Information",
+                    "type": "string"
+                },
+                "store_type": {
+                    "description": "\"online\", \"in_store\", \"both\"",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "terms_conditions": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "last change of the coupon's data, not of its votes or score",
+                    "type": "string"
+                },
+                "votes": {
+                    "description": "Voting Information",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VoteStats"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.CouponCreateRequest": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "description": "Metadata",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code": {
+                    "description": "Required Information",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount_type": {
+                    "$ref": "#/definitions/models.DiscountType"
+                },
+                "discount_value": {
+                    "type": "number"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "maximum_discount_amount": {
+                    "type": "number"
+                },
+                "merchant_name": {
+                    "type": "string"
+                },
+                "merchant_url": {
+                    "type": "string"
+                },
+                "minimum_purchase_amount": {
+                    "type": "number"
+                },
+                "regions": {
+                    "description": "countries/regions where valid",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start_date": {
+                    "description": "Optional Validity Information",
+                    "type": "string"
+                },
+                "store_type": {
+                    "description": "\"online\", \"in_store\", \"both\"",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "terms_conditions": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CouponCreateResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "edit_token": {
+                    "description": "Secret token required to edit or delete the coupon, it is only returned once.\nEmpty if the submission was merged into an existing coupon.",
+                    "type": "string",
+                    "example": "3f9a1c0d5e7b2a4c6e8f0a1b3c5d7e9f3f9a1c0d5e7b2a4c6e8f0a1b3c5d7e9f"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "merged": {
+                    "description": "True if the submission was merged into an existing coupon (on_duplicate=merge)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "score": {
+                    "type": "number"
+                },
+                "status": {
+                    "description": "Moderation state, new coupons are pending until reviewed unless submitted with a trusted API key",
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "approved",
+                        "rejected",
+                        "hidden"
+                    ],
+                    "example": "pending"
+                }
+            }
+        },
+        "models.CouponReportStats": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "coupon_id": {
+                    "type": "integer",
+                    "example": 42
                 },
-                "total": {
+                "expired": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "last_reported_at": {
+                    "type": "string"
+                },
+                "offensive": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "spam": {
                     "type": "integer",
                     "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "wrong_merchant": {
+                    "type": "integer",
+                    "example": 0
                 }
             }
         },
-        "models.Coupon": {
+        "models.CouponUpdateRequest": {
             "type": "object",
             "properties": {
                 "categories": {
-                    "description": "Metadata",
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -781,9 +2287,6 @@ const docTemplate = `{
                 "code": {
                     "type": "string"
                 },
-                "created_at": {
-                    "type": "string"
-                },
                 "description": {
                     "type": "string"
                 },
@@ -793,18 +2296,10 @@ const docTemplate = `{
                 "discount_value": {
                     "type": "number"
                 },
-                "down_votes": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
                 "end_date": {
-                    "type": "string"
-                },
-                "id": {
-                    "description": "Required Information",
-                    "type": "integer"
+                    "type": "string",
+                    "format": "date-time",
+                    "x-nullable": true
                 },
                 "maximum_discount_amount": {
                     "type": "number"
@@ -819,22 +2314,18 @@ const docTemplate = `{
                     "type": "number"
                 },
                 "regions": {
-                    "description": "countries/regions where valid",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "score": {
-                    "description": "Score calculated by db",
-                    "type": "number"
-                },
                 "start_date": {
-                    "description": "Optional Validity Information",
-                    "type": "string"
+                    "description": "Send null to remove the date",
+                    "type": "string",
+                    "format": "date-time",
+                    "x-nullable": true
                 },
                 "store_type": {
-                    "description": "\"online\", \"in_store\", \"both\"",
                     "type": "string"
                 },
                 "tags": {
@@ -848,9 +2339,205 @@ const docTemplate = `{
                 },
                 "title": {
                     "type": "string"
+                }
+            }
+        },
+        "models.CouponsSearchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Coupon"
+                    }
                 },
-                "up_votes": {
-                    "description": "Voting Information",
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "description": "Cursor of the next page, empty on the last page",
+                    "type": "string",
+                    "example": "eyJzIjoibmV3ZXN0IiwiayI6IjIwMjQtMDEtMDEgMDA6MDA6MDAiLCJpIjo0Mn0"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "description": "omitted if include_total=false",
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "models.DecayStep": {
+            "type": "object",
+            "properties": {
+                "max_age_hours": {
+                    "type": "number",
+                    "example": 24
+                },
+                "weight": {
+                    "type": "number",
+                    "example": 1
+                }
+            }
+        },
+        "models.DiscountType": {
+            "type": "string",
+            "enum": [
+                "PERCENTAGE_OFF",
+                "FIXED_AMOUNT",
+                "BOGO",
+                "FREE_SHIPPING"
+            ],
+            "x-enum-varnames": [
+                "PercentageOff",
+                "FixedAmount",
+                "BOGO",
+                "FreeShipping"
+            ]
+        },
+        "models.DuplicateCouponResponse": {
+            "type": "object",
+            "properties": {
+                "existing_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Coupon already exists"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Internal server error"
+                }
+            }
+        },
+        "models.HealthCheckResponse": {
+            "type": "object",
+            "properties": {
+                "rate_limiter": {
+                    "$ref": "#/definitions/models.RateLimiterHealthInfo"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0"
+                }
+            }
+        },
+        "models.JobRun": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 1520
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "api-1-7"
+                },
+                "job_name": {
+                    "type": "string",
+                    "example": "score_update"
+                },
+                "scheduled_for": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "succeeded",
+                        "failed"
+                    ],
+                    "example": "succeeded"
+                },
+                "trigger": {
+                    "type": "string",
+                    "enum": [
+                        "schedule",
+                        "manual"
+                    ],
+                    "example": "schedule"
+                }
+            }
+        },
+        "models.JobStatus": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "score_update"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobRun"
+                    }
+                },
+                "schedule": {
+                    "type": "string",
+                    "example": "*/10 * * * *"
+                },
+                "timeout_seconds": {
+                    "type": "integer",
+                    "example": 300
+                }
+            }
+        },
+        "models.JobsResponse": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobStatus"
+                    }
+                },
+                "workers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WorkerStatus"
+                    }
+                }
+            }
+        },
+        "models.Merchant": {
+            "type": "object",
+            "properties": {
+                "merchant_name": {
+                    "type": "string",
+                    "example": "merchant1"
+                },
+                "merchant_url": {
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -858,188 +2545,424 @@ const docTemplate = `{
                 }
             }
         },
-        "models.CouponCreateRequest": {
+        "models.MerchantResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Merchant"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.ModerationHideMerchantRequest": {
+            "type": "object",
+            "properties": {
+                "merchant_domain": {
+                    "description": "a URL of the merchant works as well",
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Merchant closed"
+                }
+            }
+        },
+        "models.ModerationHideMerchantResponse": {
+            "type": "object",
+            "properties": {
+                "hidden": {
+                    "description": "number of coupons hidden",
+                    "type": "integer",
+                    "example": 17
+                }
+            }
+        },
+        "models.ModerationLogEntry": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "moderator's key, empty for automatic changes",
+                    "type": "integer",
+                    "example": 3
+                },
+                "coupon_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "previous_status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "Joke code"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "rejected"
+                }
+            }
+        },
+        "models.ModerationLogResponse": {
             "type": "object",
             "properties": {
-                "categories": {
-                    "description": "Metadata",
+                "data": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.ModerationLogEntry"
                     }
                 },
-                "code": {
-                    "description": "Required Information",
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "discount_type": {
-                    "$ref": "#/definitions/models.DiscountType"
-                },
-                "discount_value": {
-                    "type": "number"
-                },
-                "end_date": {
-                    "type": "string"
-                },
-                "maximum_discount_amount": {
-                    "type": "number"
-                },
-                "merchant_name": {
-                    "type": "string"
-                },
-                "merchant_url": {
-                    "type": "string"
-                },
-                "minimum_purchase_amount": {
-                    "type": "number"
+                "limit": {
+                    "type": "integer",
+                    "example": 50
                 },
-                "regions": {
-                    "description": "countries/regions where valid",
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "models.ModerationQueueResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.Coupon"
                     }
                 },
-                "start_date": {
-                    "description": "Optional Validity Information",
-                    "type": "string"
+                "limit": {
+                    "type": "integer",
+                    "example": 50
                 },
-                "store_type": {
-                    "description": "\"online\", \"in_store\", \"both\"",
-                    "type": "string"
+                "offset": {
+                    "type": "integer",
+                    "example": 0
                 },
-                "tags": {
+                "total": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.ModerationRejectRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "Joke code"
+                }
+            }
+        },
+        "models.RateLimiterHealthInfo": {
+            "type": "object",
+            "properties": {
+                "breaker": {
+                    "type": "string",
+                    "enum": [
+                        "closed",
+                        "open",
+                        "half_open"
+                    ],
+                    "example": "closed"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "redis",
+                        "local",
+                        "fail_open",
+                        "fail_closed"
+                    ],
+                    "example": "redis"
+                }
+            }
+        },
+        "models.RegionResponse": {
+            "type": "object",
+            "properties": {
+                "regions": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "terms_conditions": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CouponCreateResponse": {
+        "models.ReportRequest": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
+                "comment": {
+                    "type": "string",
+                    "example": "Code was rejected at checkout"
                 },
-                "score": {
-                    "type": "number"
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "expired",
+                        "wrong_merchant",
+                        "spam",
+                        "offensive"
+                    ],
+                    "example": "expired"
                 }
             }
         },
-        "models.CouponsSearchResponse": {
+        "models.ReportStatsResponse": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Coupon"
+                        "$ref": "#/definitions/models.CouponReportStats"
                     }
                 },
                 "limit": {
                     "type": "integer",
-                    "example": 10
+                    "example": 50
                 },
                 "offset": {
                     "type": "integer",
                     "example": 0
-                },
-                "total": {
-                    "type": "integer",
-                    "example": 100
                 }
             }
         },
-        "models.DiscountType": {
-            "type": "string",
-            "enum": [
-                "PERCENTAGE_OFF",
-                "FIXED_AMOUNT",
-                "BOGO",
-                "FREE_SHIPPING"
-            ],
-            "x-enum-varnames": [
-                "PercentageOff",
-                "FixedAmount",
-                "BOGO",
-                "FreeShipping"
-            ]
-        },
-        "models.ErrorResponse": {
+        "models.ScoreComponent": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Internal server error"
+                "contribution": {
+                    "type": "number",
+                    "example": 0.3
+                },
+                "value": {
+                    "type": "number",
+                    "example": 0.75
+                },
+                "weight": {
+                    "type": "number",
+                    "example": 0.4
                 }
             }
         },
-        "models.HealthCheckResponse": {
+        "models.ScoreExplanation": {
             "type": "object",
             "properties": {
-                "status": {
-                    "type": "string",
-                    "example": "ok"
+                "coupon_id": {
+                    "type": "integer",
+                    "example": 42
                 },
-                "version": {
+                "discount": {
+                    "$ref": "#/definitions/models.ScoreComponent"
+                },
+                "freshness": {
+                    "$ref": "#/definitions/models.ScoreComponent"
+                },
+                "last_score_update": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.62
+                },
+                "settings_version": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "number",
+                    "example": 0.64
+                },
+                "vote": {
+                    "$ref": "#/definitions/models.ScoreComponent"
+                },
+                "vote_model": {
                     "type": "string",
-                    "example": "1.0"
+                    "example": "wilson"
                 }
             }
         },
-        "models.Merchant": {
+        "models.ScoreSettings": {
             "type": "object",
             "properties": {
-                "merchant_name": {
+                "comment": {
                     "type": "string",
-                    "example": "merchant1"
+                    "example": "Favour fresh coupons"
                 },
-                "merchant_url": {
+                "created_at": {
+                    "type": "string"
+                },
+                "discount_weight": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "fixed_amount_reference": {
+                    "description": "Fixed amount that counts as a full discount when the coupon has no maximum discount amount",
+                    "type": "number",
+                    "example": 1000
+                },
+                "flat_discount_score": {
+                    "description": "Discount score of BOGO and FREE_SHIPPING coupons",
+                    "type": "number",
+                    "example": 0.5
+                },
+                "freshness_decay": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.DecayStep"
+                    }
+                },
+                "freshness_decay_floor": {
+                    "type": "number",
+                    "example": 0.1
+                },
+                "freshness_weight": {
+                    "type": "number",
+                    "example": 0.2
+                },
+                "prior_mean": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "prior_weight": {
+                    "type": "number",
+                    "example": 5
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "vote_decay": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DecayStep"
                     }
+                },
+                "vote_decay_floor": {
+                    "type": "number",
+                    "example": 0.2
+                },
+                "vote_model": {
+                    "type": "string",
+                    "enum": [
+                        "net",
+                        "wilson",
+                        "bayesian"
+                    ],
+                    "example": "wilson"
+                },
+                "vote_weight": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "wilson_z": {
+                    "type": "number",
+                    "example": 1.96
                 }
             }
         },
-        "models.MerchantResponse": {
+        "models.ScoreSettingsListResponse": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Merchant"
+                        "$ref": "#/definitions/models.ScoreSettings"
                     }
                 },
-                "total": {
+                "limit": {
                     "type": "integer",
-                    "example": 2
+                    "example": 50
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
                 }
             }
         },
-        "models.RegionResponse": {
+        "models.ScoreSettingsRequest": {
             "type": "object",
             "properties": {
-                "regions": {
+                "comment": {
+                    "type": "string",
+                    "example": "Favour fresh coupons"
+                },
+                "discount_weight": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "fixed_amount_reference": {
+                    "type": "number",
+                    "example": 1000
+                },
+                "flat_discount_score": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "freshness_decay": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.DecayStep"
                     }
                 },
-                "total": {
-                    "type": "integer"
+                "freshness_decay_floor": {
+                    "type": "number",
+                    "example": 0.1
+                },
+                "freshness_weight": {
+                    "type": "number",
+                    "example": 0.2
+                },
+                "prior_mean": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "prior_weight": {
+                    "type": "number",
+                    "example": 5
+                },
+                "vote_decay": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DecayStep"
+                    }
+                },
+                "vote_decay_floor": {
+                    "type": "number",
+                    "example": 0.2
+                },
+                "vote_model": {
+                    "type": "string",
+                    "enum": [
+                        "net",
+                        "wilson",
+                        "bayesian"
+                    ],
+                    "example": "wilson"
+                },
+                "vote_weight": {
+                    "type": "number",
+                    "example": 0.4
+                },
+                "wilson_z": {
+                    "type": "number",
+                    "example": 1.96
                 }
             }
         },
@@ -1066,6 +2989,47 @@ const docTemplate = `{
                 }
             }
         },
+        "models.VoteStats": {
+            "type": "object",
+            "properties": {
+                "down": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "recent_down": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "recent_up": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "up": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "models.WorkerStatus": {
+            "type": "object",
+            "properties": {
+                "last_error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "vote_processor"
+                },
+                "restarts": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "running": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
         "syrup.Coupon": {
             "type": "object",
             "properties": {
@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"log"
+	"time"
+)
+
+// tagPrefix namespaces the Redis sets listing the keys of each tag
+const tagPrefix = "cachetag:"
+
+// generationKey counts invalidations, generationPrefix namespaces the generation of the last invalidation of each tag
+const (
+	generationKey    = "cachegen"
+	generationPrefix = "cachegen:"
+)
+
+// loadTimeout bounds a single load, loads are shared between requests and do not use a request context
+const loadTimeout = 30 * time.Second
+
+// generationTTL is how long the generation of a tag is kept, it has to outlive every load running during the invalidation
+const generationTTL = 2 * loadTimeout
+
+// ErrNotFound is returned by loaders when the requested value does not exist.
+// The miss is cached for Config.NegativeTTL and returned to every caller until then.
+var ErrNotFound = errors.New("not found")
+
+// invalidateScript deletes every key listed in the given tag sets and the sets themselves, and stores the new
+// generation as the generation of each tag. KEYS are the generation counter followed by the set and the generation
+// key of every tag, ARGV[1] is the lifetime of the tag generations in milliseconds.
+// Running it as a script keeps a concurrent set from adding a key to a set that is being dropped.
+var invalidateScript = redis.NewScript(`
+local generation = redis.call('INCR', KEYS[1])
+for t = 2, #KEYS, 2 do
+    local members = redis.call('SMEMBERS', KEYS[t])
+    for i = 1, #members, 500 do
+        redis.call('UNLINK', unpack(members, i, math.min(i + 499, #members)))
+    end
+    redis.call('UNLINK', KEYS[t])
+    redis.call('SET', KEYS[t + 1], generation, 'PX', ARGV[1])
+end
+return 0
+`)
+
+// setScript caches a value unless one of its tags was invalidated after the load started, which could have made the
+// value stale. KEYS are the entry key followed by the generation key and the set of every tag, ARGV are the value,
+// its lifetime and the lifetime of the tag sets in milliseconds, and the generation read before the load.
+var setScript = redis.NewScript(`
+for t = 2, #KEYS, 2 do
+    if tonumber(redis.call('GET', KEYS[t]) or '0') > tonumber(ARGV[4]) then
+        return 0
+    end
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+for t = 3, #KEYS, 2 do
+    redis.call('SADD', KEYS[t], KEYS[1])
+    redis.call('PEXPIRE', KEYS[t], ARGV[3])
+end
+return 1
+`)
+
+type Config struct {
+	TTL         time.Duration // how long a value is fresh
+	StaleTTL    time.Duration // how long a value is served after TTL while it is refreshed in the background
+	NegativeTTL time.Duration // how long ErrNotFound is remembered
+}
+
+// Store caches JSON encoded values in Redis.
+// Every entry is registered under one or more tags, and writes purge the entries of the tags they affect.
+// A Store without a Redis client caches nothing, but still coalesces concurrent loads.
+type Store struct {
+	rdb    *redis.Client
+	config Config
+	group  singleflight.Group
+}
+
+func New(rdb *redis.Client, config Config) *Store {
+	return &Store{rdb: rdb, config: config}
+}
+
+// entry is the cached representation of a value
+type entry struct {
+	Value      json.RawMessage `json:"v,omitempty"`
+	NotFound   bool            `json:"nf,omitempty"`
+	FreshUntil time.Time       `json:"f"`
+}
+
+// Loader loads a value on a cache miss and returns it with the tags to register it under
+type Loader[T any] func(ctx context.Context) (T, []string, error)
+
+// GetOrLoad returns the cached value of key, loading and caching it on a miss.
+//
+// Concurrent misses of the same key in this process share a single load. Stale values are returned
+// immediately while one background load refreshes them. If load returns ErrNotFound, so does GetOrLoad.
+func GetOrLoad[T any](ctx context.Context, s *Store, key string, load Loader[T]) (T, error) {
+	var value T
+
+	if cached, ok := s.get(ctx, key); ok {
+		if cached.FreshUntil.Before(time.Now()) {
+			go func() {
+				_, err, _ := s.group.Do(key, func() (interface{}, error) {
+					return s.load(key, wrap(load))
+				})
+				if err != nil && !errors.Is(err, ErrNotFound) {
+					log.Printf("Failed to refresh cache key %s: %v", key, err)
+				}
+			}()
+		}
+
+		if cached.NotFound {
+			return value, ErrNotFound
+		}
+		err := json.Unmarshal(cached.Value, &value)
+		if err == nil {
+			return value, nil
+		}
+		// If unmarshal fails, just log and continue to fetch fresh data
+		log.Printf("Failed to unmarshal cached data: %v", err)
+	}
+
+	// Wait for the shared load, unless the request gives up first
+	result := s.group.DoChan(key, func() (interface{}, error) {
+		return s.load(key, wrap(load))
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return value, res.Err
+		}
+		value, _ = res.Val.(T)
+		return value, nil
+	case <-ctx.Done():
+		return value, ctx.Err()
+	}
+}
+
+// wrap erases the type of a loader so the store can run it
+func wrap[T any](load Loader[T]) Loader[interface{}] {
+	return func(ctx context.Context) (interface{}, []string, error) {
+		return load(ctx)
+	}
+}
+
+// load runs load and caches its result
+func (s *Store) load(key string, load Loader[interface{}]) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	// The result is only cached if none of its tags is invalidated from now on, the load may have missed the write
+	generation, cacheable := s.generation(ctx)
+
+	value, tags, err := load(ctx)
+	if errors.Is(err, ErrNotFound) {
+		if cacheable {
+			s.set(ctx, key, entry{NotFound: true, FreshUntil: time.Now().Add(s.config.NegativeTTL)}, s.config.NegativeTTL, tags, generation)
+		}
+		return nil, err
+	}
+	if err != nil || !cacheable {
+		return value, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to marshal response for caching: %v", err)
+		return value, nil
+	}
+	s.set(ctx, key, entry{Value: data, FreshUntil: time.Now().Add(s.config.TTL)}, s.config.TTL+s.config.StaleTTL, tags, generation)
+
+	return value, nil
+}
+
+// tagTTL returns the lifetime of the longest living entry
+func (s *Store) tagTTL() time.Duration {
+	return max(s.config.TTL+s.config.StaleTTL, s.config.NegativeTTL)
+}
+
+func (s *Store) enabled() bool {
+	return s != nil && s.rdb != nil
+}
+
+// get returns the cached entry of key
+func (s *Store) get(ctx context.Context, key string) (entry, bool) {
+	var cached entry
+	if !s.enabled() {
+		return cached, false
+	}
+
+	data, err := s.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Failed to read cache: %v", err)
+		}
+		return cached, false
+	}
+
+	if err := json.Unmarshal(data, &cached); err != nil {
+		log.Printf("Failed to unmarshal cached data: %v", err)
+		return cached, false
+	}
+	return cached, true
+}
+
+// generation returns the number of invalidations so far, false if it cannot be read
+func (s *Store) generation(ctx context.Context) (int64, bool) {
+	if !s.enabled() {
+		return 0, false
+	}
+
+	generation, err := s.rdb.Get(ctx, generationKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Failed to read cache generation: %v", err)
+		return 0, false
+	}
+	return generation, true
+}
+
+// set caches the entry under key and registers the key with the given tags, unless one of the tags was invalidated
+// after generation
+func (s *Store) set(ctx context.Context, key string, cached entry, ttl time.Duration, tags []string, generation int64) {
+	if !s.enabled() {
+		return
+	}
+
+	data, err := json.Marshal(cached)
+	if err != nil {
+		log.Printf("Failed to marshal response for caching: %v", err)
+		return
+	}
+
+	keys := make([]string, 0, 1+2*len(tags))
+	keys = append(keys, key)
+	for _, tag := range tags {
+		keys = append(keys, generationPrefix+tag, tagPrefix+tag)
+	}
+
+	// The tag set outlives every entry it may list
+	err = setScript.Run(ctx, s.rdb, keys, data, ttl.Milliseconds(), s.tagTTL().Milliseconds(), generation).Err()
+	if err != nil {
+		log.Printf("Failed to cache response: %v", err)
+	}
+}
+
+// Invalidate removes all entries registered with any of the given tags
+func (s *Store) Invalidate(ctx context.Context, tags ...string) {
+	if !s.enabled() || len(tags) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, 1+2*len(tags))
+	keys = append(keys, generationKey)
+	for _, tag := range tags {
+		keys = append(keys, tagPrefix+tag, generationPrefix+tag)
+	}
+
+	if err := invalidateScript.Run(ctx, s.rdb, keys, generationTTL.Milliseconds()).Err(); err != nil {
+		log.Printf("Failed to invalidate cache tags %v: %v", tags, err)
+	}
+}
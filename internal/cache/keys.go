@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// keyPrefix namespaces all cache entries; bump the version when the cached representation changes
+const keyPrefix = "cache:v1:"
+
+// Key builds a namespaced cache key, e.g. Key("coupon", "42") returns "cache:v1:coupon:42"
+func Key(namespace string, parts ...string) string {
+	return keyPrefix + namespace + ":" + strings.Join(parts, ":")
+}
+
+// HashKey builds a namespaced cache key from the JSON encoding of params,
+// so that equal parameters map to the same key regardless of how they were written in the request
+func HashKey(namespace string, params interface{}) string {
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return Key(namespace, hex.EncodeToString(sum[:16]))
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// TaxonomyTag covers the merchant, category, tag and region lists
+	TaxonomyTag = "taxonomy"
+	// SearchTag covers searches that are not restricted to specific merchants, any new coupon may show up in them
+	SearchTag = "search"
+)
+
+// CouponTag covers the coupon itself and every search result page containing it
+func CouponTag(id int64) string {
+	return "coupon:" + strconv.FormatInt(id, 10)
+}
+
+// MerchantTag covers searches filtered by the given merchant name
+func MerchantTag(merchantName string) string {
+	return "merchant:" + strings.ToLower(strings.TrimSpace(merchantName))
+}
+
+// CouponTags returns the tags affected when a coupon of the given merchant is created, changed or deleted
+func CouponTags(id int64, merchantNames ...string) []string {
+	tags := []string{SearchTag, TaxonomyTag, CouponTag(id)}
+	for _, merchantName := range merchantNames {
+		tags = append(tags, MerchantTag(merchantName))
+	}
+	return tags
+}
+
+// ScoreTags returns the tags affected when the scores of coupons change, given as id -> merchant name.
+// Scores reorder searches but leave the taxonomy lists alone, so TaxonomyTag is not included.
+func ScoreTags(merchantNames map[int64]string) []string {
+	tags := make([]string, 0, 2*len(merchantNames)+1)
+	seen := make(map[string]bool, len(merchantNames))
+	if len(merchantNames) > 0 {
+		tags = append(tags, SearchTag)
+	}
+	for id, merchantName := range merchantNames {
+		tags = append(tags, CouponTag(id))
+		if tag := MerchantTag(merchantName); !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// APIKeyTag covers the cached lookups of an API key, which must end when the key is revoked or changed
+func APIKeyTag(id int64) string {
+	return "apikey:" + strconv.FormatInt(id, 10)
+}
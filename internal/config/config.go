@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -17,6 +19,19 @@ type Config struct {
 	REDISPort     string
 	REDISUser     string
 	REDISPassword string
+
+	VoterHashSalt string
+
+	// Number of reports after which a coupon is hidden or flagged for moderation, 0 disables it
+	ReportThreshold int
+	// "hide" hides reported coupons, "flag" sends them back to the moderation queue
+	ReportAction string
+
+	// What rate limiters do while Redis is unavailable: "local", "fail_open" or "fail_closed"
+	RateLimitFailurePolicy string
+
+	// Cron schedule of the score update job
+	ScoreUpdateSchedule string
 }
 
 func LoadConfig() (*Config, error) {
@@ -34,6 +49,43 @@ func LoadConfig() (*Config, error) {
 		REDISPort:     os.Getenv("REDIS_PORT"),
 		REDISUser:     os.Getenv("REDIS_USERNAME"),
 		REDISPassword: os.Getenv("REDIS_PASSWORD"),
+
+		VoterHashSalt: os.Getenv("VOTER_HASH_SALT"),
+
+		ReportThreshold: 5,
+		ReportAction:    os.Getenv("REPORT_ACTION"),
+
+		RateLimitFailurePolicy: os.Getenv("RATE_LIMIT_FAILURE_POLICY"),
+
+		ScoreUpdateSchedule: os.Getenv("SCORE_UPDATE_SCHEDULE"),
+	}
+
+	if threshold := os.Getenv("REPORT_THRESHOLD"); threshold != "" {
+		n, err := strconv.Atoi(threshold)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid REPORT_THRESHOLD: %s", threshold)
+		}
+		config.ReportThreshold = n
+	}
+
+	switch config.ReportAction {
+	case "":
+		config.ReportAction = "flag"
+	case "flag", "hide":
+	default:
+		return nil, fmt.Errorf("invalid REPORT_ACTION: %s", config.ReportAction)
+	}
+
+	switch config.RateLimitFailurePolicy {
+	case "":
+		config.RateLimitFailurePolicy = "local"
+	case "local", "fail_open", "fail_closed":
+	default:
+		return nil, fmt.Errorf("invalid RATE_LIMIT_FAILURE_POLICY: %s", config.RateLimitFailurePolicy)
+	}
+
+	if config.ScoreUpdateSchedule == "" {
+		config.ScoreUpdateSchedule = "*/10 * * * *"
 	}
 
 	return config, nil
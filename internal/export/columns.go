@@ -0,0 +1,100 @@
+package export
+
+import (
+	"discountdb-api/internal/models"
+	"time"
+)
+
+// Parquet physical types of the exported columns
+const (
+	typeInt64     = "INT64"
+	typeDouble    = "DOUBLE"
+	typeByteArray = "BYTE_ARRAY"
+)
+
+// Parquet repetitions of the exported columns
+const (
+	repetitionRequired = "REQUIRED"
+	repetitionOptional = "OPTIONAL"
+	repetitionRepeated = "REPEATED"
+)
+
+// Column describes an exported column in terms of the Parquet schema
+type Column struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	LogicalType string `json:"logical_type,omitempty"`
+	Repetition  string `json:"repetition"`
+
+	value func(coupon *models.Coupon) interface{}
+}
+
+func int64Column(name string, value func(coupon *models.Coupon) int64) Column {
+	return Column{Name: name, Type: typeInt64, Repetition: repetitionRequired, value: func(coupon *models.Coupon) interface{} {
+		return value(coupon)
+	}}
+}
+
+func doubleColumn(name string, value func(coupon *models.Coupon) float64) Column {
+	return Column{Name: name, Type: typeDouble, Repetition: repetitionRequired, value: func(coupon *models.Coupon) interface{} {
+		return value(coupon)
+	}}
+}
+
+func stringColumn(name string, value func(coupon *models.Coupon) string) Column {
+	return Column{Name: name, Type: typeByteArray, LogicalType: "STRING", Repetition: repetitionRequired, value: func(coupon *models.Coupon) interface{} {
+		return value(coupon)
+	}}
+}
+
+func listColumn(name string, value func(coupon *models.Coupon) []string) Column {
+	return Column{Name: name, Type: typeByteArray, LogicalType: "STRING", Repetition: repetitionRepeated, value: func(coupon *models.Coupon) interface{} {
+		if values := value(coupon); values != nil {
+			return values
+		}
+		return []string{}
+	}}
+}
+
+func timeColumn(name string, value func(coupon *models.Coupon) time.Time) Column {
+	return Column{Name: name, Type: typeInt64, LogicalType: "TIMESTAMP_MICROS", Repetition: repetitionRequired, value: func(coupon *models.Coupon) interface{} {
+		return value(coupon)
+	}}
+}
+
+// optionalTimeColumn is a timeColumn whose value is missing if the time is nil
+func optionalTimeColumn(name string, value func(coupon *models.Coupon) *time.Time) Column {
+	return Column{Name: name, Type: typeInt64, LogicalType: "TIMESTAMP_MICROS", Repetition: repetitionOptional, value: func(coupon *models.Coupon) interface{} {
+		if t := value(coupon); t != nil {
+			return *t
+		}
+		return nil
+	}}
+}
+
+// Columns are the exported fields of a coupon, in the order of the CSV header and the columnar schema.
+// The names match the JSON fields and the columns read by the bulk import.
+var Columns = []Column{
+	int64Column("id", func(c *models.Coupon) int64 { return c.ID }),
+	timeColumn("created_at", func(c *models.Coupon) time.Time { return c.CreatedAt }),
+	timeColumn("updated_at", func(c *models.Coupon) time.Time { return c.UpdatedAt }),
+	stringColumn("code", func(c *models.Coupon) string { return c.Code }),
+	stringColumn("title", func(c *models.Coupon) string { return c.Title }),
+	stringColumn("description", func(c *models.Coupon) string { return c.Description }),
+	doubleColumn("discount_value", func(c *models.Coupon) float64 { return c.DiscountValue }),
+	stringColumn("discount_type", func(c *models.Coupon) string { return string(c.DiscountType) }),
+	stringColumn("merchant_name", func(c *models.Coupon) string { return c.MerchantName }),
+	stringColumn("merchant_url", func(c *models.Coupon) string { return c.MerchantURL }),
+	optionalTimeColumn("start_date", func(c *models.Coupon) *time.Time { return c.StartDate }),
+	optionalTimeColumn("end_date", func(c *models.Coupon) *time.Time { return c.EndDate }),
+	stringColumn("terms_conditions", func(c *models.Coupon) string { return c.TermsConditions }),
+	doubleColumn("minimum_purchase_amount", func(c *models.Coupon) float64 { return c.MinimumPurchaseAmount }),
+	doubleColumn("maximum_discount_amount", func(c *models.Coupon) float64 { return c.MaximumDiscountAmount }),
+	listColumn("categories", func(c *models.Coupon) []string { return c.Categories }),
+	listColumn("tags", func(c *models.Coupon) []string { return c.Tags }),
+	listColumn("regions", func(c *models.Coupon) []string { return c.Regions }),
+	stringColumn("store_type", func(c *models.Coupon) string { return c.StoreType }),
+	int64Column("up_votes", func(c *models.Coupon) int64 { return c.Votes.Up }),
+	int64Column("down_votes", func(c *models.Coupon) int64 { return c.Votes.Down }),
+	doubleColumn("score", func(c *models.Coupon) float64 { return c.MaterializedScore }),
+}
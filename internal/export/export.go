@@ -0,0 +1,314 @@
+package export
+
+import (
+	"discountdb-api/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatColumnar = "columnar" // row groups of one JSON array per column with a Parquet schema
+)
+
+// ListSeparator joins the categories, tags and regions in CSV cells, it is also the default of the bulk import
+const ListSeparator = "|"
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Writer writes exported coupons in one of the export formats
+type Writer interface {
+	Write(coupon *models.Coupon) error
+	// WriteRemoval writes a coupon to be dropped from a previous export, it is called after all coupons
+	WriteRemoval(removal *models.CouponRemoval) error
+	// Close finishes the output, the underlying writer is not closed
+	Close() error
+}
+
+// NewWriter returns a Writer for format that writes to w
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatColumnar:
+		return newColumnarWriter(w)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ContentType returns the MIME type of format
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the usual file extension of format
+func Extension(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return ".ndjson"
+	case FormatCSV:
+		return ".csv"
+	default:
+		return ".json"
+	}
+}
+
+// ndjsonWriter writes every coupon as a JSON object on its own line, in the same form as the API responses
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(coupon *models.Coupon) error {
+	return w.encoder.Encode(coupon)
+}
+
+// WriteRemoval writes the removal as {"removed":{...}}, which sets it apart from the coupons
+func (w *ndjsonWriter) WriteRemoval(removal *models.CouponRemoval) error {
+	return w.encoder.Encode(struct {
+		Removed *models.CouponRemoval `json:"removed"`
+	}{removal})
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+// RemovedColumn is the last CSV column, it holds the reason of removed coupons and is empty for all others
+const RemovedColumn = "removed"
+
+// csvWriter writes a header row followed by one row per coupon and removal
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(Columns)+1)
+	for i, column := range Columns {
+		header[i] = column.Name
+	}
+	header[len(Columns)] = RemovedColumn
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, record: make([]string, len(header))}, nil
+}
+
+func (w *csvWriter) Write(coupon *models.Coupon) error {
+	for i, column := range Columns {
+		w.record[i] = csvValue(column.value(coupon))
+	}
+	w.record[len(Columns)] = ""
+	return w.writer.Write(w.record)
+}
+
+// WriteRemoval writes a row with only the id, the time of the removal as updated_at and the reason
+func (w *csvWriter) WriteRemoval(removal *models.CouponRemoval) error {
+	for i, column := range Columns {
+		switch column.Name {
+		case "id":
+			w.record[i] = csvValue(removal.ID)
+		case "updated_at":
+			w.record[i] = csvValue(removal.RemovedAt)
+		default:
+			w.record[i] = ""
+		}
+	}
+	w.record[len(Columns)] = removal.Reason
+	return w.writer.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case []string:
+		return strings.Join(v, ListSeparator)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ColumnarRowGroupSize is the number of rows per row group of the columnar format
+const ColumnarRowGroupSize = 1000
+
+// columnarWriter writes a JSON object with the schema and row groups that hold one array of values per column,
+// which map directly onto the row groups and columns of a Parquet file. Only the current row group is kept in memory.
+// The removals follow in groups of the same size under "removed", with the columns id, removed_at and reason.
+type columnarWriter struct {
+	w        io.Writer
+	columns  [][]interface{}
+	rows     int // rows of the current group
+	total    int // coupons written
+	groups   int // groups written in the current section
+	removing bool
+	removed  columnarRemovals
+}
+
+type columnarRemovals struct {
+	NumRows   int      `json:"num_rows"`
+	ID        []int64  `json:"id"`
+	RemovedAt []int64  `json:"removed_at"`
+	Reason    []string `json:"reason"`
+}
+
+func newColumnarWriter(w io.Writer) (*columnarWriter, error) {
+	schema, err := json.Marshal(Columns)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(w, `{"schema":%s,"row_groups":[`, schema); err != nil {
+		return nil, err
+	}
+	return &columnarWriter{w: w, columns: make([][]interface{}, len(Columns))}, nil
+}
+
+func (w *columnarWriter) Write(coupon *models.Coupon) error {
+	for i, column := range Columns {
+		value := column.value(coupon)
+		if t, ok := value.(time.Time); ok {
+			value = t.UnixMicro()
+		}
+		w.columns[i] = append(w.columns[i], value)
+	}
+	w.rows++
+	w.total++
+	if w.rows == ColumnarRowGroupSize {
+		return w.flushRowGroup()
+	}
+	return nil
+}
+
+func (w *columnarWriter) WriteRemoval(removal *models.CouponRemoval) error {
+	if err := w.startRemovals(); err != nil {
+		return err
+	}
+	w.removed.ID = append(w.removed.ID, removal.ID)
+	w.removed.RemovedAt = append(w.removed.RemovedAt, removal.RemovedAt.UnixMicro())
+	w.removed.Reason = append(w.removed.Reason, removal.Reason)
+	w.removed.NumRows++
+	if w.removed.NumRows == ColumnarRowGroupSize {
+		return w.flushRemovals()
+	}
+	return nil
+}
+
+func (w *columnarWriter) Close() error {
+	if err := w.startRemovals(); err != nil {
+		return err
+	}
+	if w.removed.NumRows > 0 {
+		if err := w.flushRemovals(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w.w, "],\"num_rows\":%d}\n", w.total)
+	return err
+}
+
+// flushRowGroup writes the buffered coupons as a row group
+func (w *columnarWriter) flushRowGroup() error {
+	if err := w.writeGroupSeparator(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w.w, `{"num_rows":%d,"columns":{`, w.rows); err != nil {
+		return err
+	}
+
+	// Written column by column to keep the order of the schema
+	for i, column := range Columns {
+		data, err := json.Marshal(w.columns[i])
+		if err != nil {
+			return err
+		}
+		separator := ","
+		if i == 0 {
+			separator = ""
+		}
+		if _, err := fmt.Fprintf(w.w, `%s%q:%s`, separator, column.Name, data); err != nil {
+			return err
+		}
+		w.columns[i] = w.columns[i][:0]
+	}
+	w.rows = 0
+
+	_, err := io.WriteString(w.w, "}}")
+	return err
+}
+
+// startRemovals ends the row groups of the coupons, the first time it is called
+func (w *columnarWriter) startRemovals() error {
+	if w.removing {
+		return nil
+	}
+	if w.rows > 0 {
+		if err := w.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	w.removing = true
+	w.groups = 0
+	_, err := io.WriteString(w.w, `],"removed":[`)
+	return err
+}
+
+// flushRemovals writes the buffered removals as a group
+func (w *columnarWriter) flushRemovals() error {
+	if err := w.writeGroupSeparator(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(w.removed)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+	w.removed = columnarRemovals{
+		ID:        w.removed.ID[:0],
+		RemovedAt: w.removed.RemovedAt[:0],
+		Reason:    w.removed.Reason[:0],
+	}
+	return nil
+}
+
+func (w *columnarWriter) writeGroupSeparator() error {
+	w.groups++
+	if w.groups == 1 {
+		return nil
+	}
+	_, err := io.WriteString(w.w, ",")
+	return err
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetModerationLog godoc
+// @Summary List moderation decisions
+// @Description List the moderation log, newest first. Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param coupon_id query int false "Only the decisions about this coupon"
+// @Param limit query int false "Maximum number of entries to return" minimum(1) maximum(200) default(50)
+// @Param offset query int false "Number of entries to skip" minimum(0) default(0)
+// @Success 200 {object} models.ModerationLogResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/moderation/log [get]
+func GetModerationLog(c *fiber.Ctx, moderationRepo *repositories.ModerationRepository) error {
+	couponID := c.QueryInt("coupon_id", 0)
+	if couponID < 0 {
+		return &repositories.ValidationError{Field: "coupon_id", Message: "Invalid coupon_id parameter"}
+	}
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := moderationRepo.Log(c.Context(), int64(couponID), limit, offset)
+	if err != nil {
+		return fmt.Errorf("failed to get moderation log: %w", err)
+	}
+
+	return c.JSON(models.ModerationLogResponse{
+		Data:   entries,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetModerationQueue godoc
+// @Summary List the moderation queue
+// @Description List coupons by moderation state, oldest first. Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param status query string false "Moderation state" Enums(pending, approved, rejected, hidden) default(pending)
+// @Param limit query int false "Maximum number of coupons to return" minimum(1) maximum(200) default(50)
+// @Param offset query int false "Number of coupons to skip" minimum(0) default(0)
+// @Success 200 {object} models.ModerationQueueResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/moderation/queue [get]
+func GetModerationQueue(c *fiber.Ctx, moderationRepo *repositories.ModerationRepository) error {
+	status := c.Query("status", models.ModerationPending)
+	switch status {
+	case models.ModerationPending, models.ModerationApproved, models.ModerationRejected, models.ModerationHidden:
+	default:
+		return &repositories.ValidationError{Field: "status", Message: "Invalid status parameter"}
+	}
+
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+
+	coupons, total, err := moderationRepo.Queue(c.Context(), status, limit, offset)
+	if err != nil {
+		return fmt.Errorf("failed to get moderation queue: %w", err)
+	}
+
+	return c.JSON(models.ModerationQueueResponse{
+		Data:   coupons,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
@@ -0,0 +1,39 @@
+package admin
+
+import (
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetReports godoc
+// @Summary List reported coupons
+// @Description List the reported coupons with their number of reports per reason, most reported first. Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param limit query int false "Maximum number of coupons to return" minimum(1) maximum(200) default(50)
+// @Param offset query int false "Number of coupons to skip" minimum(0) default(0)
+// @Success 200 {object} models.ReportStatsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/reports [get]
+func GetReports(c *fiber.Ctx, reportRepo *repositories.ReportRepository) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+
+	stats, err := reportRepo.Stats(c.Context(), limit, offset)
+	if err != nil {
+		return fmt.Errorf("failed to get report stats: %w", err)
+	}
+
+	return c.JSON(models.ReportStatsResponse{
+		Data:   stats,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"discountdb-api/internal/jobs"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Number of runs listed per job
+const (
+	defaultJobRuns = 10
+	maxJobRuns     = 100
+)
+
+// GetJobs godoc
+// @Summary List background jobs
+// @Description List the scheduled jobs with their latest runs, newest first, and the workers of the replica answering the request.
+// @Description Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param runs query int false "Number of runs to list per job" minimum(1) maximum(100) default(10)
+// @Success 200 {object} models.JobsResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/jobs [get]
+func GetJobs(c *fiber.Ctx, scheduler *jobs.Scheduler) error {
+	runs := c.QueryInt("runs", defaultJobRuns)
+	if runs < 1 || runs > maxJobRuns {
+		return &repositories.ValidationError{Field: "runs", Message: "Invalid runs parameter"}
+	}
+
+	status, err := scheduler.Status(c.Context(), runs)
+	if err != nil {
+		return fmt.Errorf("failed to get job status: %w", err)
+	}
+
+	return c.JSON(status)
+}
+
+// PostRunJob godoc
+// @Summary Run a background job
+// @Description Start a run of a scheduled job now, it runs in the background. Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param name path string true "Job name"
+// @Success 200 {object} models.JobRun
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 409 {object} models.ErrorResponse "Job is already running"
+// @Router /admin/jobs/{name}/run [post]
+func PostRunJob(c *fiber.Ctx, scheduler *jobs.Scheduler) error {
+	run, err := scheduler.Trigger(c.Context(), c.Params("name"))
+	if err != nil {
+		return fmt.Errorf("failed to run job: %w", err)
+	}
+
+	return c.JSON(run)
+}
@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"discountdb-api/internal/middleware"
+	"discountdb-api/internal/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page sizes of the moderation listings
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+var errInvalidCouponID = &repositories.ValidationError{Field: "id", Message: "Invalid coupon ID"}
+
+// pagination returns the limit and offset query parameters
+func pagination(c *fiber.Ctx) (int, int, error) {
+	limit := c.QueryInt("limit", defaultLimit)
+	if limit < 1 || limit > maxLimit {
+		return 0, 0, &repositories.ValidationError{Field: "limit", Message: "Invalid limit parameter"}
+	}
+
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		return 0, 0, &repositories.ValidationError{Field: "offset", Message: "Invalid offset parameter"}
+	}
+
+	return limit, offset, nil
+}
+
+// moderatorID returns the id of the moderator's API key, which is recorded in the moderation log and with score settings
+func moderatorID(c *fiber.Ctx) *int64 {
+	if key := middleware.APIKeyFromCtx(c); key != nil {
+		return &key.ID
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// setStatus changes the moderation state of the coupon in the id parameter
+func setStatus(c *fiber.Ctx, moderationRepo *repositories.ModerationRepository, store *cache.Store, status, reason string) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return errInvalidCouponID
+	}
+
+	merchantName, err := moderationRepo.SetStatus(c.Context(), int64(id), status, reason, moderatorID(c))
+	if err != nil {
+		return fmt.Errorf("failed to set moderation status: %w", err)
+	}
+
+	// The coupon appears in or disappears from every listing of its merchant
+	store.Invalidate(c.Context(), cache.CouponTags(int64(id), merchantName)...)
+
+	return c.JSON(models.Success{Message: "Coupon " + status})
+}
+
+// PostApproveCoupon godoc
+// @Summary Approve a coupon
+// @Description Make a coupon public. Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param id path int true "Coupon ID"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Failure 404 {object} models.ErrorResponse "Coupon not found"
+// @Router /admin/coupons/{id}/approve [post]
+func PostApproveCoupon(c *fiber.Ctx, moderationRepo *repositories.ModerationRepository, store *cache.Store) error {
+	return setStatus(c, moderationRepo, store, models.ModerationApproved, "")
+}
+
+// PostRejectCoupon godoc
+// @Summary Reject a coupon
+// @Description Reject a coupon with a reason, it is no longer public. Requires an API key with the admin scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param id path int true "Coupon ID"
+// @Param request body models.ModerationRejectRequest true "Reason of the rejection"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Failure 404 {object} models.ErrorResponse "Coupon not found"
+// @Router /admin/coupons/{id}/reject [post]
+func PostRejectCoupon(c *fiber.Ctx, moderationRepo *repositories.ModerationRepository, store *cache.Store) error {
+	var request models.ModerationRejectRequest
+	if err := c.BodyParser(&request); err != nil {
+		return &repositories.ValidationError{Message: "Invalid request payload"}
+	}
+
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return &repositories.ValidationError{Field: "reason", Message: "Reason is required"}
+	}
+
+	return setStatus(c, moderationRepo, store, models.ModerationRejected, reason)
+}
+
+// PostHideMerchant godoc
+// @Summary Hide all coupons of a merchant
+// @Description Hide every pending or approved coupon of a merchant domain. Requires an API key with the admin scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param request body models.ModerationHideMerchantRequest true "Merchant domain and reason"
+// @Success 200 {object} models.ModerationHideMerchantResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/merchants/hide [post]
+func PostHideMerchant(c *fiber.Ctx, moderationRepo *repositories.ModerationRepository, store *cache.Store) error {
+	var request models.ModerationHideMerchantRequest
+	if err := c.BodyParser(&request); err != nil {
+		return &repositories.ValidationError{Message: "Invalid request payload"}
+	}
+
+	domain := strings.TrimSpace(request.MerchantDomain)
+	if domain == "" {
+		return &repositories.ValidationError{Field: "merchant_domain", Message: "Merchant domain is required"}
+	}
+
+	ids, merchantNames, err := moderationRepo.HideMerchant(c.Context(), domain, strings.TrimSpace(request.Reason), moderatorID(c))
+	if err != nil {
+		return fmt.Errorf("failed to hide merchant: %w", err)
+	}
+
+	if len(ids) > 0 {
+		tags := []string{cache.SearchTag, cache.TaxonomyTag}
+		seen := make(map[string]bool)
+		for _, merchantName := range merchantNames {
+			if tag := cache.MerchantTag(merchantName); !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+		for _, id := range ids {
+			tags = append(tags, cache.CouponTag(id))
+		}
+		store.Invalidate(c.Context(), tags...)
+	}
+
+	return c.JSON(models.ModerationHideMerchantResponse{Hidden: len(ids)})
+}
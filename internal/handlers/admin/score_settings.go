@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// maxDecaySteps limits the length of the decay curves, which are evaluated for every vote
+const maxDecaySteps = 20
+
+// validateDecay checks a decay curve and its floor weight
+func validateDecay(field string, steps []models.DecayStep, floor float64) error {
+	if len(steps) > maxDecaySteps {
+		return &repositories.ValidationError{Field: field, Message: fmt.Sprintf("At most %d decay steps are allowed", maxDecaySteps)}
+	}
+	for _, step := range steps {
+		if step.MaxAgeHours <= 0 {
+			return &repositories.ValidationError{Field: field, Message: "Decay step max_age_hours must be positive"}
+		}
+		if step.Weight < 0 {
+			return &repositories.ValidationError{Field: field, Message: "Decay step weight must not be negative"}
+		}
+	}
+	if floor < 0 {
+		return &repositories.ValidationError{Field: field + "_floor", Message: "Decay floor must not be negative"}
+	}
+	return nil
+}
+
+// validateScoreSettings checks the request and fills in the defaults of the vote model parameters
+func validateScoreSettings(request *models.ScoreSettingsRequest) error {
+	switch request.VoteModel {
+	case "":
+		request.VoteModel = models.VoteModelNet
+	case models.VoteModelNet, models.VoteModelWilson, models.VoteModelBayesian:
+	default:
+		return &repositories.ValidationError{Field: "vote_model", Message: "Invalid vote model"}
+	}
+	// 0 is a valid value of every parameter, so only missing ones get the defaults
+	if request.WilsonZ == nil {
+		wilsonZ := models.DefaultWilsonZ
+		request.WilsonZ = &wilsonZ
+	}
+	if request.PriorMean == nil {
+		priorMean := models.DefaultPriorMean
+		request.PriorMean = &priorMean
+	}
+	if request.PriorWeight == nil {
+		priorWeight := float64(models.DefaultPriorWeight)
+		request.PriorWeight = &priorWeight
+	}
+	if *request.WilsonZ < 0 {
+		return &repositories.ValidationError{Field: "wilson_z", Message: "Wilson z must not be negative"}
+	}
+	if *request.PriorMean < 0 || *request.PriorMean > 1 {
+		return &repositories.ValidationError{Field: "prior_mean", Message: "Prior mean must be between 0 and 1"}
+	}
+	if *request.PriorWeight < 0 {
+		return &repositories.ValidationError{Field: "prior_weight", Message: "Prior weight must not be negative"}
+	}
+
+	if request.VoteWeight < 0 || request.DiscountWeight < 0 || request.FreshnessWeight < 0 {
+		return &repositories.ValidationError{Message: "Weights must not be negative"}
+	}
+	if request.VoteWeight+request.DiscountWeight+request.FreshnessWeight == 0 {
+		return &repositories.ValidationError{Message: "At least one weight must be positive"}
+	}
+	if err := validateDecay("vote_decay", request.VoteDecay, request.VoteDecayFloor); err != nil {
+		return err
+	}
+	if err := validateDecay("freshness_decay", request.FreshnessDecay, request.FreshnessDecayFloor); err != nil {
+		return err
+	}
+	if request.FixedAmountReference <= 0 {
+		return &repositories.ValidationError{Field: "fixed_amount_reference", Message: "Fixed amount reference must be positive"}
+	}
+	if request.FlatDiscountScore < 0 || request.FlatDiscountScore > 1 {
+		return &repositories.ValidationError{Field: "flat_discount_score", Message: "Flat discount score must be between 0 and 1"}
+	}
+	return nil
+}
+
+// GetScoreSettings godoc
+// @Summary List score settings versions
+// @Description List the versions of the ranking parameters, newest first. The newest version is in use. Requires an API key with the admin scope.
+// @Tags admin
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param limit query int false "Maximum number of versions to return" minimum(1) maximum(200) default(50)
+// @Param offset query int false "Number of versions to skip" minimum(0) default(0)
+// @Success 200 {object} models.ScoreSettingsListResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/score-settings [get]
+func GetScoreSettings(c *fiber.Ctx, scoreRepo *repositories.ScoreRepository) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return err
+	}
+
+	versions, err := scoreRepo.ListSettings(c.Context(), limit, offset)
+	if err != nil {
+		return fmt.Errorf("failed to get score settings: %w", err)
+	}
+
+	return c.JSON(models.ScoreSettingsListResponse{
+		Data:   versions,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// PostScoreSettings godoc
+// @Summary Create a score settings version
+// @Description Store new ranking parameters as the next version, which is used from now on.
+// @Description All scores are recomputed by the next score update. To roll back, post an older version again.
+// @Description Requires an API key with the admin scope.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API key with the admin scope"
+// @Param request body models.ScoreSettingsRequest true "Ranking parameters"
+// @Success 200 {object} models.ScoreSettings
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the admin scope"
+// @Router /admin/score-settings [post]
+func PostScoreSettings(c *fiber.Ctx, scoreRepo *repositories.ScoreRepository) error {
+	var request models.ScoreSettingsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return &repositories.ValidationError{Message: "Invalid request payload"}
+	}
+	request.Comment = strings.TrimSpace(request.Comment)
+
+	if err := validateScoreSettings(&request); err != nil {
+		return err
+	}
+
+	settings, err := scoreRepo.CreateSettings(c.Context(), request, moderatorID(c))
+	if err != nil {
+		return fmt.Errorf("failed to create score settings: %w", err)
+	}
+
+	return c.JSON(settings)
+}
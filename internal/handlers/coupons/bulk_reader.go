@@ -0,0 +1,255 @@
+package coupons
+
+import (
+	"bufio"
+	"bytes"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input formats of the bulk import
+const (
+	bulkFormatJSON   = "json"
+	bulkFormatNDJSON = "ndjson"
+	bulkFormatCSV    = "csv"
+)
+
+// maxNDJSONLine is the longest accepted NDJSON line
+const maxNDJSONLine = 1 << 20
+
+// bulkRowFunc receives every row of the input in order, err is set if the row could not be parsed.
+// Returning an error stops reading.
+type bulkRowFunc func(coupon models.CouponCreateRequest, err error) error
+
+// csvFields are the fields that can be mapped to CSV columns, by default they are read from the column of the same name
+var csvFields = []string{
+	"code", "title", "description", "discount_value", "discount_type",
+	"merchant_name", "merchant_url", "start_date", "end_date",
+	"terms_conditions", "minimum_purchase_amount", "maximum_discount_amount",
+	"categories", "tags", "regions", "store_type",
+}
+
+// csvOptions configures how CSV rows are turned into coupons
+type csvOptions struct {
+	mapping       map[string]string // field name -> column name
+	listSeparator string            // separates the values of categories, tags and regions
+}
+
+// parseCSVMapping parses a column mapping of the form "field=column,field=column"
+func parseCSVMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if s == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		column = strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("invalid mapping entry: %s", pair)
+		}
+		if !isCSVField(field) {
+			return nil, fmt.Errorf("unknown mapping field: %s", field)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+func isCSVField(field string) bool {
+	for _, f := range csvFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// readJSONArray reads a JSON array of coupons element by element
+func readJSONArray(r io.Reader, fn bulkRowFunc) error {
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return &repositories.ValidationError{Message: "Request body must be a JSON array"}
+	}
+
+	for row := 1; decoder.More(); row++ {
+		var coupon models.CouponCreateRequest
+		err := decoder.Decode(&coupon)
+
+		// A value of the wrong type is consumed completely, so the following rows can still be read
+		var typeErr *json.UnmarshalTypeError
+		if err != nil && !errors.As(err, &typeErr) {
+			return &repositories.ValidationError{Message: fmt.Sprintf("Invalid JSON in row %d: %v", row, err)}
+		}
+
+		if err := fn(coupon, err); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return &repositories.ValidationError{Message: "Request body must be a JSON array"}
+	}
+	return nil
+}
+
+// readNDJSON reads one coupon per line, blank lines are skipped
+func readNDJSON(r io.Reader, fn bulkRowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var coupon models.CouponCreateRequest
+		if err := fn(coupon, json.Unmarshal(line, &coupon)); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return &repositories.ValidationError{Message: fmt.Sprintf("NDJSON lines must not exceed %d bytes", maxNDJSONLine)}
+		}
+		return err
+	}
+	return nil
+}
+
+// readCSV reads coupons from a CSV file whose first row names the columns
+func readCSV(r io.Reader, options csvOptions, fn bulkRowFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return &repositories.ValidationError{Message: "CSV input must start with a header row"}
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// Resolve the column of every field, mapped fields must exist
+	fieldColumns := make(map[string]int, len(csvFields))
+	for _, field := range csvFields {
+		column, mapped := options.mapping[field]
+		if !mapped {
+			column = field
+		}
+		if i, ok := columns[strings.ToLower(column)]; ok {
+			fieldColumns[field] = i
+		} else if mapped {
+			return &repositories.ValidationError{Field: "mapping", Message: fmt.Sprintf("Mapped column %s not found in CSV header", column)}
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var coupon models.CouponCreateRequest
+		if err == nil {
+			err = csvCoupon(record, fieldColumns, options.listSeparator, &coupon)
+		} else {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+		}
+
+		if err := fn(coupon, err); err != nil {
+			return err
+		}
+	}
+}
+
+// csvCoupon fills coupon from a CSV record
+func csvCoupon(record []string, fieldColumns map[string]int, listSeparator string, coupon *models.CouponCreateRequest) error {
+	value := func(field string) string {
+		if i, ok := fieldColumns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	number := func(field string) (float64, error) {
+		v := value(field)
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %s", field, v)
+		}
+		return n, nil
+	}
+	date := func(field string) (*time.Time, error) {
+		v := value(field)
+		if v == "" {
+			return nil, nil
+		}
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", field, v)
+		}
+		return &t, nil
+	}
+	list := func(field string) []string {
+		var values []string
+		for _, v := range strings.Split(value(field), listSeparator) {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+
+	coupon.Code = value("code")
+	coupon.Title = value("title")
+	coupon.Description = value("description")
+	coupon.DiscountType = models.DiscountType(strings.ToUpper(value("discount_type")))
+	coupon.MerchantName = value("merchant_name")
+	coupon.MerchantURL = value("merchant_url")
+	coupon.TermsConditions = value("terms_conditions")
+	coupon.StoreType = value("store_type")
+	coupon.Categories = list("categories")
+	coupon.Tags = list("tags")
+	coupon.Regions = list("regions")
+
+	var err error
+	if coupon.DiscountValue, err = number("discount_value"); err != nil {
+		return err
+	}
+	if coupon.MinimumPurchaseAmount, err = number("minimum_purchase_amount"); err != nil {
+		return err
+	}
+	if coupon.MaximumDiscountAmount, err = number("maximum_discount_amount"); err != nil {
+		return err
+	}
+
+	if coupon.StartDate, err = date("start_date"); err != nil {
+		return err
+	}
+	if coupon.EndDate, err = date("end_date"); err != nil {
+		return err
+	}
+
+	return nil
+}
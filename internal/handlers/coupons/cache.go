@@ -0,0 +1,15 @@
+package coupons
+
+import (
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/repositories"
+	"strconv"
+)
+
+// errInvalidCouponID is returned for coupon ids in the path that are not positive integers
+var errInvalidCouponID = &repositories.ValidationError{Field: "id", Message: "Invalid coupon ID"}
+
+// couponCacheKey returns the cache key of a single coupon
+func couponCacheKey(id int64) string {
+	return cache.Key("coupon", strconv.FormatInt(id, 10))
+}
@@ -0,0 +1,44 @@
+package coupons
+
+import (
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeleteCoupon godoc
+// @Summary Delete a coupon
+// @Description Delete a coupon, requires the edit token returned when the coupon was created
+// @Tags coupons
+// @Produce json
+// @Param id path int true "Coupon ID"
+// @Param X-Edit-Token header string true "Edit token returned when the coupon was created"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 403 {object} models.ErrorResponse "Invalid edit token for an approved coupon"
+// @Failure 404 {object} models.ErrorResponse "Coupon not found, or not approved and the edit token is invalid"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /coupons/{id} [delete]
+func DeleteCoupon(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return errInvalidCouponID
+	}
+
+	editToken := c.Get(editTokenHeader)
+	if editToken == "" {
+		return errEditTokenRequired
+	}
+
+	merchantName, err := couponRepo.Delete(c.Context(), int64(id), hashEditToken(editToken))
+	if err != nil {
+		return fmt.Errorf("failed to delete coupon: %w", err)
+	}
+
+	// Merchant filtered searches count the coupon even on pages that do not list it
+	store.Invalidate(c.Context(), cache.CouponTags(int64(id), merchantName)...)
+
+	return c.JSON(models.Success{Message: "Coupon successfully deleted"})
+}
@@ -0,0 +1,30 @@
+package coupons
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"discountdb-api/internal/repositories"
+	"encoding/hex"
+)
+
+// editTokenHeader is the request header carrying the edit token returned by PostCoupon
+const editTokenHeader = "X-Edit-Token"
+
+var errEditTokenRequired = &repositories.Error{Kind: repositories.ErrForbidden, Message: "Edit token is required"}
+
+// newEditToken returns a random edit token and the hash that is stored with the coupon
+func newEditToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	token := hex.EncodeToString(buf)
+	return token, hashEditToken(token), nil
+}
+
+// hashEditToken returns the hex encoded SHA-256 of token, only hashes are stored in the database
+func hashEditToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
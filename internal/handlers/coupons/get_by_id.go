@@ -1,12 +1,13 @@
 package coupons
 
 import (
+	"context"
+	"discountdb-api/internal/cache"
 	"discountdb-api/internal/models"
 	"discountdb-api/internal/repositories"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
-	"log"
 )
 
 // GetCouponByID godoc
@@ -20,42 +21,26 @@ import (
 // @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /coupons/{id} [get]
-func GetCouponByID(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) error {
+func GetCouponByID(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
 	id, err := c.ParamsInt("id")
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Message: "Invalid coupon ID"})
+	if err != nil || id < 1 {
+		return errInvalidCouponID
 	}
 
-	// redis cache
-	key := "coupon:id:" + string(id)
+	coupon, err := cache.GetOrLoad(c.Context(), store, couponCacheKey(int64(id)), func(ctx context.Context) (*models.Coupon, []string, error) {
+		tags := []string{cache.CouponTag(int64(id))}
 
-	var response fiber.Map
-	if rdb != nil {
-		if cached, err := rdb.Get(c.Context(), key).Result(); err == nil {
-			if err := json.Unmarshal([]byte(cached), &response); err == nil {
-				return c.JSON(response)
-			}
-			// If unmarshal fails, just log and continue to fetch fresh data
-			log.Printf("Failed to unmarshal cached data: %v", err)
+		coupon, err := couponRepo.GetByID(ctx, int64(id))
+		if errors.Is(err, repositories.ErrNotFound) {
+			err = cache.ErrNotFound
 		}
+		return coupon, tags, err
+	})
+	if errors.Is(err, cache.ErrNotFound) {
+		return repositories.ErrCouponNotFound
 	}
-
-	// Get coupon by ID if not in cache
-	coupon, err := couponRepo.GetByID(c.Context(), int64(id))
 	if err != nil {
-		log.Printf("Failed to get coupon: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Message: "Failed to get coupon"})
-	}
-
-	// Set cache
-	if rdb != nil {
-		if cached, err := json.Marshal(coupon); err == nil {
-			if err := rdb.Set(c.Context(), key, cached, cacheExpire).Err(); err != nil {
-				log.Printf("Failed to cache response: %v", err)
-			}
-		} else {
-			log.Printf("Failed to marshal response for caching: %v", err)
-		}
+		return fmt.Errorf("failed to get coupon: %w", err)
 	}
 
 	return c.JSON(coupon)
@@ -1,21 +1,21 @@
 package coupons
 
 import (
+	"context"
+	"discountdb-api/internal/cache"
 	"discountdb-api/internal/models"
 	"discountdb-api/internal/repositories"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
-	"log"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const (
 	defaultLimit  = 10
 	defaultOffset = 0
-	cacheExpire   = 5 * time.Minute
 )
 
 // ParseSearchParams extracts and validates search parameters from the request
@@ -23,13 +23,6 @@ func ParseSearchParams(c *fiber.Ctx) (repositories.SearchParams, error) {
 	params := repositories.SearchParams{
 		Limit:  defaultLimit,
 		Offset: defaultOffset,
-		SearchIn: []string{
-			"code",
-			"title",
-			"description",
-			"merchant_name",
-			"merchant_url",
-		},
 	}
 
 	// Parse search string
@@ -69,92 +62,223 @@ func ParseSearchParams(c *fiber.Ctx) (repositories.SearchParams, error) {
 		params.Offset = offset
 	}
 
+	// Parse cursor, which replaces offset based pagination
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if params.Offset != 0 {
+			return params, fmt.Errorf("cursor and offset cannot be combined")
+		}
+		cursor, err := repositories.DecodeCursor(cursorStr, params)
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			return params, fmt.Errorf("invalid cursor parameter: %s", cursorStr)
+		}
+		if err != nil {
+			return params, err
+		}
+		params.Cursor = cursor
+	}
+
+	// Parse include_total
+	if includeTotal := c.Query("include_total"); includeTotal != "" {
+		include, err := strconv.ParseBool(includeTotal)
+		if err != nil {
+			return params, fmt.Errorf("invalid include_total parameter: %s", includeTotal)
+		}
+		params.SkipTotal = !include
+	}
+
+	// Parse filters
+	params.Merchants = queryValues(c, "merchant")
+	params.Categories = queryValues(c, "category")
+	params.Tags = queryValues(c, "tag")
+	params.Regions = queryValues(c, "region")
+
+	params.DiscountTypes = queryValues(c, "discount_type")
+	for _, discountType := range params.DiscountTypes {
+		if !isValidDiscountType(models.DiscountType(discountType)) {
+			return params, fmt.Errorf("invalid discount_type parameter: %s", discountType)
+		}
+	}
+
+	params.StoreTypes = queryValues(c, "store_type")
+	for _, storeType := range params.StoreTypes {
+		if !isValidStoreType(storeType) {
+			return params, fmt.Errorf("invalid store_type parameter: %s", storeType)
+		}
+	}
+
+	match := c.Query("match", string(repositories.MatchAny))
+	params.Match = repositories.MatchMode(match)
+	if params.Match != repositories.MatchAny && params.Match != repositories.MatchAll {
+		return params, fmt.Errorf("invalid match parameter: %s", match)
+	}
+
+	// Parse validity filters
+	active := c.Query("active", string(repositories.ValidityActive))
+	params.Active = repositories.Validity(active)
+	if params.Active != repositories.ValidityActive &&
+		params.Active != repositories.ValidityInactive &&
+		params.Active != repositories.ValidityAll {
+		return params, fmt.Errorf("invalid active parameter: %s", active)
+	}
+
+	if expiresBefore := c.Query("expires_before"); expiresBefore != "" {
+		t, err := parseTime(expiresBefore)
+		if err != nil {
+			return params, fmt.Errorf("invalid expires_before parameter: %s", expiresBefore)
+		}
+		params.ExpiresBefore = &t
+	}
+
+	if expiresAfter := c.Query("expires_after"); expiresAfter != "" {
+		t, err := parseTime(expiresAfter)
+		if err != nil {
+			return params, fmt.Errorf("invalid expires_after parameter: %s", expiresAfter)
+		}
+		params.ExpiresAfter = &t
+	}
+
 	return params, nil
 }
 
+// parseTime accepts either a RFC 3339 timestamp or a plain date (YYYY-MM-DD)
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// queryValues returns all non-empty values of a repeatable query parameter (e.g. ?tag=a&tag=b)
+func queryValues(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, value := range c.Context().QueryArgs().PeekMulti(key) {
+		if v := strings.TrimSpace(string(value)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func isValidDiscountType(t models.DiscountType) bool {
+	switch t {
+	case models.PercentageOff, models.FixedAmount, models.BOGO, models.FreeShipping:
+		return true
+	default:
+		return false
+	}
+}
+
+func isValidStoreType(s string) bool {
+	switch s {
+	case "online", "in_store", "both":
+		return true
+	default:
+		return false
+	}
+}
+
 func isValidSortBy(s repositories.SortBy) bool {
 	switch s {
-	case repositories.SortByNewest, repositories.SortByOldest, repositories.SortByHighScore, repositories.SortByLowScore:
+	case repositories.SortByNewest, repositories.SortByOldest, repositories.SortByHighScore, repositories.SortByLowScore,
+		repositories.SortByRelevance:
 		return true
 	default:
 		return false
 	}
 }
 
-func SearchCoupons(params repositories.SearchParams, c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) (*models.CouponsSearchResponse, error) {
-	// Just use the raw query string as the cache key
-	key := "coupons:" + string(c.Request().URI().QueryString())
-
-	// Try to get from cache
-	var response models.CouponsSearchResponse
-	if rdb != nil {
-		if cached, err := rdb.Get(c.Context(), key).Result(); err == nil {
-			if err := json.Unmarshal([]byte(cached), &response); err == nil {
-				return &response, nil
-			}
-			// If unmarshal fails, just log and continue to fetch fresh data
-			log.Printf("Failed to unmarshal cached data: %v", err)
-		}
+// searchCacheTags returns the tags of a cached search: every coupon on the page,
+// and the merchants it is restricted to or SearchTag if it may contain coupons of any merchant
+func searchCacheTags(params repositories.SearchParams, coupons []models.Coupon) []string {
+	var tags []string
+	if len(params.Merchants) == 0 {
+		tags = append(tags, cache.SearchTag)
+	}
+	for _, merchant := range params.Merchants {
+		tags = append(tags, cache.MerchantTag(merchant))
 	}
+	for _, coupon := range coupons {
+		tags = append(tags, cache.CouponTag(coupon.ID))
+	}
+	return tags
+}
 
-	// Search for coupons if not in cache
-	coupons, err := couponRepo.Search(c.Context(), params)
+func SearchCoupons(params repositories.SearchParams, c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) (*models.CouponsSearchResponse, error) {
+	// The parameters describe the search completely, unlike the raw query string which differs between endpoints
+	key := cache.HashKey("search", params)
+
+	response, err := cache.GetOrLoad(c.Context(), store, key, func(ctx context.Context) (*models.CouponsSearchResponse, []string, error) {
+		return searchCoupons(ctx, params, couponRepo)
+	})
 	if err != nil {
-		log.Printf("Failed to search coupons: %v", err)
-		return nil, c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Message: "Failed to search coupons"})
+		return nil, fmt.Errorf("failed to search coupons: %w", err)
 	}
 
-	total, err := couponRepo.GetTotalCount(c.Context(), params)
+	return response, nil
+}
+
+// searchCoupons runs the search and returns the response with its cache tags
+func searchCoupons(ctx context.Context, params repositories.SearchParams, couponRepo *repositories.CouponRepository) (*models.CouponsSearchResponse, []string, error) {
+	coupons, next, err := couponRepo.Search(ctx, params)
 	if err != nil {
-		log.Printf("Failed to get total count: %v", err)
-		return nil, c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Message: "Failed to get total count"})
+		return nil, nil, err
 	}
 
-	// Prepare response
-	response = models.CouponsSearchResponse{
+	response := models.CouponsSearchResponse{
 		Data:   coupons,
-		Total:  int(total),
 		Limit:  params.Limit,
 		Offset: params.Offset,
 	}
+	if next != nil {
+		response.NextCursor = next.Encode()
+	}
 
-	// Cache the response
-	if rdb != nil {
-		if cached, err := json.Marshal(response); err == nil {
-			if err := rdb.Set(c.Context(), key, cached, cacheExpire).Err(); err != nil {
-				log.Printf("Failed to cache response: %v", err)
-			}
-		} else {
-			log.Printf("Failed to marshal response for caching: %v", err)
+	if !params.SkipTotal {
+		total, err := couponRepo.GetTotalCount(ctx, params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get total count: %w", err)
 		}
+		response.Total = &total
 	}
 
-	return &response, nil
+	return &response, searchCacheTags(params, coupons), nil
 }
 
 // GetCoupons godoc
 // @Summary Get coupons with filtering and pagination
-// @Description Retrieve a list of coupons with optional search, sorting, and pagination
+// @Description Retrieve a list of coupons with optional search, filtering, sorting, and pagination
 // @Tags coupons
 // @Accept json
 // @Produce json
-// @Param q query string false "Search query string"
-// @Param sort_by query string false "Sort order (newest, oldest, high_score, low_score)" Enums(newest, oldest, high_score, low_score) default(newest)
+// @Param q query string false "Full-text search query, supports quoted phrases, prefix* and -excluded terms"
+// @Param sort_by query string false "Sort order (newest, oldest, high_score, low_score, relevance)" Enums(newest, oldest, high_score, low_score, relevance) default(newest)
 // @Param limit query integer false "Number of items per page" minimum(1) default(10)
 // @Param offset query integer false "Number of items to skip" minimum(0) default(0)
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page, replaces offset"
+// @Param include_total query boolean false "Whether to count all matching coupons, disable for infinite scrolling" default(true)
+// @Param merchant query []string false "Filter by merchant name (repeatable, any value matches)" collectionFormat(multi)
+// @Param category query []string false "Filter by category (repeatable)" collectionFormat(multi)
+// @Param tag query []string false "Filter by tag (repeatable)" collectionFormat(multi)
+// @Param region query []string false "Filter by region (repeatable)" collectionFormat(multi)
+// @Param discount_type query []string false "Filter by discount type (repeatable, any value matches)" collectionFormat(multi) Enums(PERCENTAGE_OFF, FIXED_AMOUNT, BOGO, FREE_SHIPPING)
+// @Param store_type query []string false "Filter by store type (repeatable, any value matches)" collectionFormat(multi) Enums(online, in_store, both)
+// @Param match query string false "Whether category, tag and region filters require any or all of the given values" Enums(any, all) default(any)
+// @Param active query string false "Validity filter: only active, only expired/not yet started, or all coupons" Enums(true, false, all) default(true)
+// @Param expires_before query string false "Only coupons with an end date before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param expires_after query string false "Only coupons still valid after this time, including coupons without an end date (RFC 3339 or YYYY-MM-DD)"
 // @Success 200 {object} models.CouponsSearchResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /coupons/search [get]
-func GetCoupons(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) error {
+func GetCoupons(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
 	// Get url parameters
 	params, err := ParseSearchParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Message: err.Error()})
+		return &repositories.ValidationError{Message: err.Error()}
 	}
 
 	// Search for coupons
-	response, err := SearchCoupons(params, c, couponRepo, rdb)
+	response, err := SearchCoupons(params, c, couponRepo, store)
 	if err != nil {
 		return err
 	}
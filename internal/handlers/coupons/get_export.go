@@ -0,0 +1,77 @@
+package coupons
+
+import (
+	"bufio"
+	"context"
+	"discountdb-api/internal/export"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"time"
+)
+
+// exportTimeout limits how long a single export may stream
+const exportTimeout = 30 * time.Minute
+
+// GetCouponsExport godoc
+// @Summary Export all coupons
+// @Description Stream the full dataset as NDJSON, CSV or columnar JSON, ordered by the time of the last change. Pass the largest updated_at or removed_at of the previous export as updated_since to only receive coupons changed since then, followed by the coupons deleted or un-published since then: NDJSON lines of the form {"removed":{"id","removed_at","reason"}}, CSV rows with only id, updated_at and the reason in the last column "removed", or the "removed" groups of the columnar format. The CSV lists are separated by "|", like the default of the bulk import. The columnar format holds a Parquet schema and row groups of 1000 rows with one array per column, timestamps are microseconds since the Unix epoch.
+// @Tags coupons
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param format query string false "Output format" Enums(ndjson, csv, columnar) default(ndjson)
+// @Param updated_since query string false "Only coupons changed at or after this time (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {string} string "Exported coupons"
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Router /coupons/export [get]
+func GetCouponsExport(c *fiber.Ctx, couponRepo *repositories.CouponRepository) error {
+	format := c.Query("format", export.FormatNDJSON)
+	switch format {
+	case export.FormatNDJSON, export.FormatCSV, export.FormatColumnar:
+	default:
+		return &repositories.ValidationError{Field: "format", Message: "Invalid format parameter"}
+	}
+
+	var params repositories.ExportParams
+	if updatedSince := c.Query("updated_since"); updatedSince != "" {
+		t, err := parseTime(updatedSince)
+		if err != nil {
+			return &repositories.ValidationError{Field: "updated_since", Message: "Invalid updated_since parameter"}
+		}
+		params.UpdatedSince = &t
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="coupons%s"`, export.Extension(format)))
+
+	// The body is written after the handler returned, so errors can only be logged and end the response early
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		writer, err := export.NewWriter(w, format)
+		if err == nil {
+			err = couponRepo.Export(ctx, params, func(coupon *models.Coupon) error {
+				return writer.Write(coupon)
+			})
+		}
+		if err == nil && params.UpdatedSince != nil {
+			err = couponRepo.ExportRemovals(ctx, *params.UpdatedSince, func(removal *models.CouponRemoval) error {
+				return writer.WriteRemoval(removal)
+			})
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Printf("Failed to export coupons: %v", err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,33 @@
+package coupons
+
+import (
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetCouponScore godoc
+// @Summary Explain a coupon's score
+// @Description Break the score of a coupon down into its vote, discount and freshness parts under the current score settings.
+// @Description The stored score used for sorting is recomputed periodically, so it can lag behind the total.
+// @Tags coupons
+// @Produce json
+// @Param id path int true "Coupon ID"
+// @Success 200 {object} models.ScoreExplanation
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 404 {object} models.ErrorResponse "Coupon not found"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /coupons/{id}/score [get]
+func GetCouponScore(c *fiber.Ctx, scoreRepo *repositories.ScoreRepository) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return errInvalidCouponID
+	}
+
+	explanation, err := scoreRepo.Explain(c.Context(), int64(id))
+	if err != nil {
+		return fmt.Errorf("failed to explain score: %w", err)
+	}
+
+	return c.JSON(explanation)
+}
@@ -1,45 +1,23 @@
 package coupons
 
 import (
+	"context"
+	"discountdb-api/internal/cache"
 	"discountdb-api/internal/models"
 	"discountdb-api/internal/repositories"
-	"encoding/json"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
-	"log"
 )
 
-func GetTagsResponse(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) (*models.TagResponse, error) {
-	// redis cache
-	key := "tags"
+func GetTagsResponse(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) (*models.TagResponse, error) {
+	key := cache.Key("taxonomy", "tags")
 
-	var response models.TagResponse
-	if rdb != nil {
-		if cached, err := rdb.Get(c.Context(), key).Result(); err == nil {
-			if err := json.Unmarshal([]byte(cached), &response); err == nil {
-				return &response, nil
-			}
-			// If unmarshal fails, just log and continue to fetch fresh data
-			log.Printf("Failed to unmarshal cached data: %v", err)
-		}
-	}
-
-	// Get tags if not in cache
-	tags, err := couponRepo.GetTags(c.Context())
+	tags, err := cache.GetOrLoad(c.Context(), store, key, func(ctx context.Context) (*models.TagResponse, []string, error) {
+		tags, err := couponRepo.GetTags(ctx)
+		return tags, []string{cache.TaxonomyTag}, err
+	})
 	if err != nil {
-		log.Printf("Failed to get tags: %v", err)
-		return nil, c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Message: "Failed to get tags"})
-	}
-
-	// Set cache
-	if rdb != nil {
-		if cached, err := json.Marshal(tags); err == nil {
-			if err := rdb.Set(c.Context(), key, cached, cacheExpire).Err(); err != nil {
-				log.Printf("Failed to cache response: %v", err)
-			}
-		} else {
-			log.Printf("Failed to marshal response for caching: %v", err)
-		}
+		return nil, fmt.Errorf("failed to get tags: %w", err)
 	}
 
 	return tags, nil
@@ -53,8 +31,8 @@ func GetTagsResponse(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rd
 // @Success 200 {object} models.TagResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /coupons/tags [get]
-func GetTags(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) error {
-	tags, err := GetTagsResponse(c, couponRepo, rdb)
+func GetTags(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
+	tags, err := GetTagsResponse(c, couponRepo, store)
 
 	if err != nil {
 		return err
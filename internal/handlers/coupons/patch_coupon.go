@@ -0,0 +1,160 @@
+package coupons
+
+import (
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"time"
+)
+
+// applyCouponUpdate merges the provided fields of the update into a create request based on the current coupon
+func applyCouponUpdate(coupon *models.Coupon, update *models.CouponUpdateRequest) models.CouponCreateRequest {
+	request := models.CouponCreateRequest{
+		Code:                  coupon.Code,
+		Title:                 coupon.Title,
+		Description:           coupon.Description,
+		DiscountValue:         coupon.DiscountValue,
+		DiscountType:          coupon.DiscountType,
+		MerchantName:          coupon.MerchantName,
+		MerchantURL:           coupon.MerchantURL,
+		StartDate:             coupon.StartDate,
+		EndDate:               coupon.EndDate,
+		TermsConditions:       coupon.TermsConditions,
+		MinimumPurchaseAmount: coupon.MinimumPurchaseAmount,
+		MaximumDiscountAmount: coupon.MaximumDiscountAmount,
+		Categories:            coupon.Categories,
+		Tags:                  coupon.Tags,
+		Regions:               coupon.Regions,
+		StoreType:             coupon.StoreType,
+	}
+
+	if update.Code != nil {
+		request.Code = *update.Code
+	}
+	if update.Title != nil {
+		request.Title = *update.Title
+	}
+	if update.Description != nil {
+		request.Description = *update.Description
+	}
+	if update.DiscountValue != nil {
+		request.DiscountValue = *update.DiscountValue
+	}
+	if update.DiscountType != nil {
+		request.DiscountType = *update.DiscountType
+	}
+	if update.MerchantName != nil {
+		request.MerchantName = *update.MerchantName
+	}
+	if update.MerchantURL != nil {
+		request.MerchantURL = *update.MerchantURL
+	}
+	if update.StartDate.Set {
+		request.StartDate = update.StartDate.Value
+	}
+	if update.EndDate.Set {
+		request.EndDate = update.EndDate.Value
+	}
+	if update.TermsConditions != nil {
+		request.TermsConditions = *update.TermsConditions
+	}
+	if update.MinimumPurchaseAmount != nil {
+		request.MinimumPurchaseAmount = *update.MinimumPurchaseAmount
+	}
+	if update.MaximumDiscountAmount != nil {
+		request.MaximumDiscountAmount = *update.MaximumDiscountAmount
+	}
+	if update.Categories != nil {
+		request.Categories = *update.Categories
+	}
+	if update.Tags != nil {
+		request.Tags = *update.Tags
+	}
+	if update.Regions != nil {
+		request.Regions = *update.Regions
+	}
+	if update.StoreType != nil {
+		request.StoreType = *update.StoreType
+	}
+
+	return request
+}
+
+// PatchCoupon godoc
+// @Summary Update a coupon
+// @Description Update the given fields of a coupon, requires the edit token returned when the coupon was created. Dates are removed by sending null. The changed coupon is reviewed again unless submitted with a trusted API key.
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param id path int true "Coupon ID"
+// @Param X-Edit-Token header string true "Edit token returned when the coupon was created"
+// @Param coupon body models.CouponUpdateRequest true "Fields to update"
+// @Success 200 {object} models.Coupon
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 404 {object} models.ErrorResponse "Coupon not found or invalid edit token"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /coupons/{id} [patch]
+func PatchCoupon(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return errInvalidCouponID
+	}
+
+	editToken := c.Get(editTokenHeader)
+	if editToken == "" {
+		return errEditTokenRequired
+	}
+
+	// Submitters may still fix coupons that are waiting for review or were rejected. The token is checked
+	// before anything else, so others cannot tell whether such a coupon exists.
+	editTokenHash := hashEditToken(editToken)
+	coupon, err := couponRepo.GetByEditToken(c.Context(), int64(id), editTokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	var update models.CouponUpdateRequest
+	if err := c.BodyParser(&update); err != nil {
+		log.Printf("Error parsing update coupon request body: %v", err)
+		return errInvalidPayload
+	}
+
+	previousMerchant := coupon.MerchantName
+
+	request := applyCouponUpdate(coupon, &update)
+	if err := validateCoupon(&request); err != nil {
+		return err
+	}
+
+	coupon.Code = request.Code
+	coupon.Title = request.Title
+	coupon.Description = request.Description
+	coupon.DiscountValue = request.DiscountValue
+	coupon.DiscountType = request.DiscountType
+	coupon.MerchantName = request.MerchantName
+	coupon.MerchantURL = request.MerchantURL
+	coupon.StartDate = request.StartDate
+	coupon.EndDate = request.EndDate
+	coupon.TermsConditions = request.TermsConditions
+	coupon.MinimumPurchaseAmount = request.MinimumPurchaseAmount
+	coupon.MaximumDiscountAmount = request.MaximumDiscountAmount
+	coupon.Categories = request.Categories
+	coupon.Tags = request.Tags
+	coupon.Regions = request.Regions
+	coupon.StoreType = request.StoreType
+
+	// Changes have to be reviewed again
+	coupon.ModerationStatus = submissionStatus(c)
+
+	if err := couponRepo.Update(c.Context(), coupon, editTokenHash); err != nil {
+		return fmt.Errorf("failed to update coupon: %w", err)
+	}
+	coupon.IsActive = coupon.IsActiveAt(time.Now())
+
+	store.Invalidate(c.Context(), cache.CouponTags(coupon.ID, previousMerchant, coupon.MerchantName)...)
+
+	return c.JSON(coupon)
+}
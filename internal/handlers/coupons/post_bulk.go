@@ -0,0 +1,243 @@
+package coupons
+
+import (
+	"bytes"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBulkRows is the largest number of rows accepted by a single bulk import
+const maxBulkRows = 10000
+
+// maxBulkBytes is the largest body accepted by a single bulk import, in any format
+const maxBulkBytes = 32 << 20
+
+var errBulkTooLarge = fiber.NewError(fiber.StatusRequestEntityTooLarge, fmt.Sprintf("A bulk import must not exceed %d MB", maxBulkBytes>>20))
+
+// bulkFormat returns the input format from the format parameter, falling back to the Content-Type header
+func bulkFormat(c *fiber.Ctx) (string, error) {
+	if format := c.Query("format"); format != "" {
+		switch format {
+		case bulkFormatJSON, bulkFormatNDJSON, bulkFormatCSV:
+			return format, nil
+		}
+		return "", &repositories.ValidationError{Field: "format", Message: "Invalid format parameter"}
+	}
+
+	contentType := strings.ToLower(string(c.Request().Header.ContentType()))
+	switch {
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+		return bulkFormatNDJSON, nil
+	case strings.Contains(contentType, "csv"):
+		return bulkFormatCSV, nil
+	default:
+		return bulkFormatJSON, nil
+	}
+}
+
+// cappedBody reads the request body up to one byte past maxBulkBytes, so an oversized body can be detected
+type cappedBody struct {
+	r io.Reader
+	n int64
+}
+
+func (b *cappedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *cappedBody) exceeded() bool {
+	return b.n > maxBulkBytes
+}
+
+// bulkBody returns the request body as a stream, so large imports are not buffered twice
+func bulkBody(c *fiber.Ctx) *cappedBody {
+	var r io.Reader
+	if stream := c.Context().RequestBodyStream(); stream != nil {
+		r = stream
+	} else {
+		r = bytes.NewReader(c.Body())
+	}
+	return &cappedBody{r: io.LimitReader(r, maxBulkBytes+1)}
+}
+
+// PostCouponsBulk godoc
+// @Summary Import coupons in bulk
+// @Description Import coupons from a JSON array, NDJSON or CSV of up to 32 MB and 10000 rows. Every row is validated like a single submission and reported separately. Rows whose code already exists for the merchant domain are skipped as duplicates. All created coupons are written in one transaction and wait for moderation unless the API key is trusted.
+// @Tags coupons
+// @Accept json
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Produce json
+// @Param X-API-Key header string true "API key with the bulk scope"
+// @Param format query string false "Input format, detected from the Content-Type header if omitted" Enums(json, ndjson, csv)
+// @Param mapping query string false "CSV column mapping as comma separated field=column pairs, unmapped fields are read from the column of the same name"
+// @Param list_separator query string false "Separator of the categories, tags and regions in CSV cells" default(|)
+// @Param dry_run query boolean false "Only validate the rows and report duplicates, nothing is written" default(false)
+// @Success 200 {object} models.BulkImportResponse
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 401 {object} models.ErrorResponse "Invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the bulk scope"
+// @Failure 413 {object} models.ErrorResponse "Request body exceeds 32 MB"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /coupons/bulk [post]
+func PostCouponsBulk(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
+	if c.Request().Header.ContentLength() > maxBulkBytes {
+		// The body is left unread, the connection cannot be reused
+		c.Context().SetConnectionClose()
+		return errBulkTooLarge
+	}
+
+	format, err := bulkFormat(c)
+	if err != nil {
+		return err
+	}
+
+	dryRun := false
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			return &repositories.ValidationError{Field: "dry_run", Message: "Invalid dry_run parameter"}
+		}
+	}
+
+	mapping, err := parseCSVMapping(c.Query("mapping"))
+	if err != nil {
+		return &repositories.ValidationError{Field: "mapping", Message: "Invalid mapping parameter: " + err.Error()}
+	}
+
+	response := models.BulkImportResponse{DryRun: dryRun, Results: []models.BulkImportRowResult{}}
+
+	// Validate every row, remembering the row number of each valid coupon
+	var coupons []models.Coupon
+	var couponRows []int
+	now := time.Now()
+	status := submissionStatus(c)
+
+	row := 0
+	readRow := func(request models.CouponCreateRequest, err error) error {
+		row++
+		if row > maxBulkRows {
+			return &repositories.ValidationError{Message: fmt.Sprintf("A bulk import must not exceed %d rows", maxBulkRows)}
+		}
+
+		if err == nil {
+			err = validateCoupon(&request)
+		}
+		if err != nil {
+			response.Results = append(response.Results, models.BulkImportRowResult{
+				Row:    row,
+				Status: models.BulkRowInvalid,
+				Error:  bulkRowError(err),
+			})
+			return nil
+		}
+
+		coupons = append(coupons, models.Coupon{
+			CreatedAt:             now,
+			Code:                  request.Code,
+			Title:                 request.Title,
+			Description:           request.Description,
+			DiscountValue:         request.DiscountValue,
+			DiscountType:          request.DiscountType,
+			MerchantName:          request.MerchantName,
+			MerchantURL:           request.MerchantURL,
+			StartDate:             request.StartDate,
+			EndDate:               request.EndDate,
+			TermsConditions:       request.TermsConditions,
+			MinimumPurchaseAmount: request.MinimumPurchaseAmount,
+			MaximumDiscountAmount: request.MaximumDiscountAmount,
+			Categories:            request.Categories,
+			Tags:                  request.Tags,
+			Regions:               request.Regions,
+			StoreType:             request.StoreType,
+			ModerationStatus:      status,
+		})
+		couponRows = append(couponRows, row)
+		response.Results = append(response.Results, models.BulkImportRowResult{Row: row})
+		return nil
+	}
+
+	body := bulkBody(c)
+	switch format {
+	case bulkFormatNDJSON:
+		err = readNDJSON(body, readRow)
+	case bulkFormatCSV:
+		err = readCSV(body, csvOptions{mapping: mapping, listSeparator: c.Query("list_separator", "|")}, readRow)
+	default:
+		err = readJSONArray(body, readRow)
+	}
+	// The parser only sees a truncated body, whatever it reported
+	if body.exceeded() {
+		c.Context().SetConnectionClose()
+		return errBulkTooLarge
+	}
+	if err != nil {
+		return err
+	}
+
+	results, err := couponRepo.CreateBatch(c.Context(), coupons, dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to import coupons: %w", err)
+	}
+
+	for i, result := range results {
+		report := &response.Results[couponRows[i]-1]
+		switch {
+		case result.ExistingID != 0:
+			report.Status = models.BulkRowDuplicate
+			report.ExistingID = result.ExistingID
+		case result.DuplicateOf >= 0:
+			report.Status = models.BulkRowDuplicate
+			report.DuplicateOfRow = couponRows[result.DuplicateOf]
+		case dryRun:
+			report.Status = models.BulkRowValid
+		default:
+			report.Status = models.BulkRowCreated
+			report.ID = result.ID
+		}
+	}
+
+	response.Total = len(response.Results)
+	for _, report := range response.Results {
+		switch report.Status {
+		case models.BulkRowCreated:
+			response.Created++
+		case models.BulkRowValid:
+			response.Valid++
+		case models.BulkRowInvalid:
+			response.Invalid++
+		case models.BulkRowDuplicate:
+			response.Duplicates++
+		}
+	}
+
+	if response.Created > 0 {
+		tags := []string{cache.SearchTag, cache.TaxonomyTag}
+		merchants := make(map[string]bool)
+		for i, result := range results {
+			if result.ID != 0 && !merchants[coupons[i].MerchantName] {
+				merchants[coupons[i].MerchantName] = true
+				tags = append(tags, cache.MerchantTag(coupons[i].MerchantName))
+			}
+		}
+		store.Invalidate(c.Context(), tags...)
+	}
+
+	return c.JSON(response)
+}
+
+// bulkRowError returns the message reported for an invalid row
+func bulkRowError(err error) string {
+	if validation, ok := err.(*repositories.ValidationError); ok {
+		return validation.Message
+	}
+	return "Invalid row: " + err.Error()
+}
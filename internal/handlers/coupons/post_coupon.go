@@ -1,94 +1,113 @@
 package coupons
 
 import (
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/middleware"
 	"discountdb-api/internal/models"
 	"discountdb-api/internal/repositories"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
 	"log"
 	"strings"
 	"time"
 )
 
+// errInvalidPayload is returned for request bodies that cannot be parsed
+var errInvalidPayload = &repositories.ValidationError{Message: "Invalid request payload"}
+
 func ValidateCouponRequest(c *fiber.Ctx) (*models.CouponCreateRequest, error) {
 	var coupon models.CouponCreateRequest
 
 	if err := c.BodyParser(&coupon); err != nil {
 		log.Printf("Error parsing create coupon request body: %v", err)
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Invalid request payload",
-		})
+		return nil, errInvalidPayload
+	}
+
+	if err := validateCoupon(&coupon); err != nil {
+		return nil, err
 	}
 
-	// Validate coupon fields
+	return &coupon, nil
+}
+
+// validateCoupon normalizes the coupon and returns a *repositories.ValidationError describing the first invalid field, if any
+func validateCoupon(coupon *models.CouponCreateRequest) error {
 	if coupon.Code == "" {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Coupon code is required",
-		})
+		return &repositories.ValidationError{Field: "code", Message: "Coupon code is required"}
 	}
 
 	if coupon.Title == "" {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Coupon title is required",
-		})
+		return &repositories.ValidationError{Field: "title", Message: "Coupon title is required"}
 	}
 
 	if coupon.Description == "" {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Coupon description is required",
-		})
+		return &repositories.ValidationError{Field: "description", Message: "Coupon description is required"}
 	}
 
 	if coupon.MerchantName == "" {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Merchant name is required",
-		})
+		return &repositories.ValidationError{Field: "merchant_name", Message: "Merchant name is required"}
 	}
 
 	if coupon.MerchantURL == "" {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Merchant URL is required",
-		})
+		return &repositories.ValidationError{Field: "merchant_url", Message: "Merchant URL is required"}
 	}
 
 	coupon.MerchantURL = strings.TrimPrefix(coupon.MerchantURL, "https://")
 	coupon.MerchantURL = strings.TrimPrefix(coupon.MerchantURL, "http://")
 
 	if coupon.DiscountValue == 0 && coupon.DiscountType != models.FreeShipping && coupon.DiscountType != models.BOGO {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Discount value is required",
-		})
+		return &repositories.ValidationError{Field: "discount_value", Message: "Discount value is required"}
 	}
 
-	if coupon.DiscountType != models.PercentageOff &&
-		coupon.DiscountType != models.FixedAmount &&
-		coupon.DiscountType != models.FreeShipping &&
-		coupon.DiscountType != models.BOGO {
-		return nil, c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Invalid discount type",
-		})
+	if !isValidDiscountType(coupon.DiscountType) {
+		return &repositories.ValidationError{Field: "discount_type", Message: "Invalid discount type"}
 	}
 
-	return &coupon, nil
+	if coupon.StoreType != "" && !isValidStoreType(coupon.StoreType) {
+		return &repositories.ValidationError{Field: "store_type", Message: "Invalid store type"}
+	}
+
+	return nil
+}
+
+// submissionStatus returns the moderation state of a submitted coupon, trusted API keys skip the review
+func submissionStatus(c *fiber.Ctx) string {
+	if key := middleware.APIKeyFromCtx(c); key != nil && key.Trusted {
+		return models.ModerationApproved
+	}
+	return models.ModerationPending
 }
 
 // PostCoupon godoc
 // @Summary Create a new coupon
-// @Description Create a new coupon
+// @Description Create a new coupon. The response contains a secret edit token required to update or delete it later. New coupons are only public once approved by a moderator, unless submitted with a trusted API key.
 // @Tags coupons
 // @Accept json
 // @Produce json
 // @Param coupon body models.CouponCreateRequest true "CouponCreateRequest object"
+// @Param on_duplicate query string false "What to do if the code already exists for the merchant domain: reject with 409 or merge tags, categories, regions and dates into the existing coupon, which then waits for moderation again unless the API key is trusted. Merging needs a trusted API key or the edit token of the existing coupon, otherwise it is rejected with 409 as well. Rejected and hidden coupons are ignored" Enums(reject, merge) default(reject)
+// @Param X-Edit-Token header string false "Edit token of the existing coupon, allows on_duplicate=merge without a trusted API key"
 // @Success 200 {object} models.CouponCreateResponse
 // @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 409 {object} models.DuplicateCouponResponse "Coupon already exists"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
 // @Router /coupons [post]
-func PostCoupon(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) error {
+func PostCoupon(c *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
+	duplicateMode := repositories.DuplicateMode(c.Query("on_duplicate", string(repositories.DuplicateReject)))
+	if duplicateMode != repositories.DuplicateReject && duplicateMode != repositories.DuplicateMerge {
+		return &repositories.ValidationError{Field: "on_duplicate", Message: "Invalid on_duplicate parameter"}
+	}
+
 	couponRequest, err := ValidateCouponRequest(c)
 	if err != nil {
 		return err
 	}
 
+	editToken, editTokenHash, err := newEditToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate edit token: %w", err)
+	}
+
 	// Create coupon
 	coupon := models.Coupon{
 		ID:                    0,
@@ -105,21 +124,39 @@ func PostCoupon(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *re
 		TermsConditions:       couponRequest.TermsConditions,
 		MinimumPurchaseAmount: couponRequest.MinimumPurchaseAmount,
 		MaximumDiscountAmount: couponRequest.MaximumDiscountAmount,
-		UpVotes:               models.TimestampArray{},
-		DownVotes:             models.TimestampArray{},
 		Categories:            couponRequest.Categories,
 		Tags:                  couponRequest.Tags,
 		Regions:               couponRequest.Regions,
 		StoreType:             couponRequest.StoreType,
 		MaterializedScore:     0,
 		LastScoreUpdate:       nil,
+		EditTokenHash:         editTokenHash,
+		ModerationStatus:      submissionStatus(c),
+	}
+
+	// Only the submitter of the existing coupon or a trusted API key may merge into it
+	ownerTokenHash := ""
+	if token := c.Get(editTokenHeader); token != "" {
+		ownerTokenHash = hashEditToken(token)
 	}
 
 	// Save coupon
-	if err := couponRepo.Create(c.Context(), &coupon); err != nil {
-		log.Printf("Failed to create coupon: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Message: "Failed to create coupon",
+	merged, err := couponRepo.CreateUnique(c.Context(), &coupon, duplicateMode, ownerTokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	// A new coupon may show up in any search of its merchant, a merged one also changed in place
+	store.Invalidate(c.Context(), cache.CouponTags(coupon.ID, coupon.MerchantName)...)
+
+	// The edit token only belongs to the original submitter
+	if merged {
+		return c.JSON(models.CouponCreateResponse{
+			ID:                coupon.ID,
+			MaterializedScore: coupon.MaterializedScore,
+			CreatedAt:         coupon.CreatedAt,
+			Merged:            true,
+			Status:            coupon.ModerationStatus,
 		})
 	}
 
@@ -127,5 +164,7 @@ func PostCoupon(c *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *re
 		ID:                coupon.ID,
 		MaterializedScore: coupon.MaterializedScore,
 		CreatedAt:         coupon.CreatedAt,
+		EditToken:         editToken,
+		Status:            coupon.ModerationStatus,
 	})
 }
@@ -0,0 +1,99 @@
+package coupons
+
+import (
+	"crypto/sha256"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/middleware"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"encoding/hex"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReportComment is the longest accepted report comment in characters
+const maxReportComment = 1000
+
+// ReportPolicy decides what happens to coupons that are reported too often
+type ReportPolicy struct {
+	Threshold int    // number of reporters since the last moderation that triggers Status, 0 disables it
+	Status    string // models.ModerationHidden or models.ModerationPending
+}
+
+// ReporterHash returns the hashed identity of the reporter, the API key or else the client IP.
+// Unlike VoterHash it ignores the voter token, which clients could change to report a coupon many times.
+func ReporterHash(c *fiber.Ctx, salt string) string {
+	sum := sha256.Sum256([]byte(salt + "|" + middleware.ClientID(c)))
+	return hex.EncodeToString(sum[:])
+}
+
+func isValidReportReason(reason string) bool {
+	switch reason {
+	case models.ReportExpired, models.ReportWrongMerchant, models.ReportSpam, models.ReportOffensive:
+		return true
+	}
+	return false
+}
+
+// PostReport godoc
+// @Summary Report a coupon
+// @Description Report an expired, misattributed, spam or offensive coupon. Each reporter (API key, or else IP) has one report per coupon, reporting again replaces it. Coupons reported by too many reporters since they were last moderated are hidden or sent back to moderation.
+// @Tags coupons
+// @Accept json
+// @Produce json
+// @Param id path int true "Coupon ID"
+// @Param report body models.ReportRequest true "Reason and optional comment"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.ErrorResponse "Bad Request"
+// @Failure 404 {object} models.ErrorResponse "Coupon not found"
+// @Failure 429 {object} models.ErrorResponse "Too Many Requests"
+// @Router /coupons/{id}/report [post]
+func PostReport(c *fiber.Ctx, reportRepo *repositories.ReportRepository, moderationRepo *repositories.ModerationRepository, store *cache.Store, voterHashSalt string, policy ReportPolicy) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return errInvalidCouponID
+	}
+
+	var request models.ReportRequest
+	if err := c.BodyParser(&request); err != nil {
+		return errInvalidPayload
+	}
+
+	if !isValidReportReason(request.Reason) {
+		return &repositories.ValidationError{Field: "reason", Message: "Invalid report reason"}
+	}
+
+	comment := strings.TrimSpace(request.Comment)
+	if utf8.RuneCountInString(comment) > maxReportComment {
+		return &repositories.ValidationError{Field: "comment", Message: fmt.Sprintf("Comment must not exceed %d characters", maxReportComment)}
+	}
+
+	reports, err := reportRepo.Create(c.Context(), models.Report{
+		CouponID:     int64(id),
+		Reason:       request.Reason,
+		Comment:      comment,
+		ReporterHash: ReporterHash(c, voterHashSalt),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	if policy.Threshold > 0 && reports >= int64(policy.Threshold) {
+		reason := fmt.Sprintf("Reported by %d reporters", reports)
+		changed, merchantName, err := moderationRepo.Flag(c.Context(), int64(id), policy.Status, reason)
+		if err != nil {
+			return fmt.Errorf("failed to flag reported coupon: %w", err)
+		}
+		if changed {
+			log.Printf("Coupon %d reported by %d reporters, moderation status set to %s", id, reports, policy.Status)
+			store.Invalidate(c.Context(), cache.CouponTags(int64(id), merchantName)...)
+		}
+	}
+
+	return c.JSON(models.Success{
+		Message: "Report received",
+	})
+}
@@ -1,39 +1,53 @@
 package coupons
 
 import (
-	"context"
+	"crypto/sha256"
 	"discountdb-api/internal/models"
+	"discountdb-api/internal/queue"
 	"discountdb-api/internal/repositories"
-	"encoding/json"
+	"encoding/hex"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"strconv"
 	"time"
 )
 
-type VoteQueue struct {
-	ID        int64     `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	VoteType  string    `json:"vote_type"`
+// voterTokenHeader optionally identifies a voter, e.g. a random id stored by the browser extension
+const voterTokenHeader = "X-Voter-Token"
+
+// VoterHash returns the hashed identity of the voter, made from the client IP and the optional voter token header.
+// The salt keeps the hashes from being reversed by enumerating IP addresses.
+func VoterHash(c *fiber.Ctx, salt string) string {
+	sum := sha256.Sum256([]byte(salt + "|" + c.IP() + "|" + c.Get(voterTokenHeader)))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnqueueVote adds a vote for the given coupon to the vote stream
+func EnqueueVote(c *fiber.Ctx, rdb *redis.Client, id int64, voteType string, voterHash string) error {
+	return queue.EnqueueVote(c.Context(), rdb, queue.VoteMessage{
+		ID:        id,
+		Timestamp: time.Now(),
+		VoteType:  voteType,
+		VoterHash: voterHash,
+	})
 }
 
 // PostVote godoc
 // @Summary Vote on a coupon
-// @Description Vote on a coupon by ID
+// @Description Vote on a coupon by ID. Each voter (IP and optional X-Voter-Token) has one vote per coupon, voting again in the other direction changes it.
 // @Tags votes
 // @Produce json
 // @Param dir path string true "Vote direction (up or down)"
 // @Param id path string true "Coupon ID"
+// @Param X-Voter-Token header string false "Optional client token identifying the voter"
 // @Success 200 {object} models.Success
 // @Failure 400 {object} models.ErrorResponse
 // @Router /coupons/vote/{dir}/{id} [post]
-func PostVote(c *fiber.Ctx, rdb *redis.Client) error {
+func PostVote(c *fiber.Ctx, rdb *redis.Client, voterHashSalt string) error {
 	// Get vote direction
 	dir := c.Params("dir")
 	if dir != "up" && dir != "down" {
-		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Message: "Invalid vote direction",
-		})
+		return &repositories.ValidationError{Field: "dir", Message: "Invalid vote direction"}
 	}
 
 	vote := models.VoteBody{
@@ -41,28 +55,12 @@ func PostVote(c *fiber.Ctx, rdb *redis.Client) error {
 	}
 
 	if id, err := strconv.Atoi(c.Params("id")); err != nil || id < 1 {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			models.ErrorResponse{
-				Message: "Invalid coupon ID",
-			},
-		)
+		return errInvalidCouponID
 	} else {
 		vote.ID = int64(id)
 	}
 
-	voteQueue := VoteQueue{
-		ID:        vote.ID,
-		Timestamp: time.Now(),
-		VoteType:  vote.Dir,
-	}
-
-	queueJSON, err := json.Marshal(voteQueue)
-	if err != nil {
-		return err
-	}
-
-	err = rdb.RPush(c.Context(), "vote_queue", queueJSON).Err()
-	if err != nil {
+	if err := EnqueueVote(c, rdb, vote.ID, vote.Dir, VoterHash(c, voterHashSalt)); err != nil {
 		return err
 	}
 
@@ -71,49 +69,27 @@ func PostVote(c *fiber.Ctx, rdb *redis.Client) error {
 	})
 }
 
-func ProcessVoteQueue(ctx context.Context, couponRepo *repositories.CouponRepository, rdb *redis.Client, batchSize int) error {
-	for {
-		// Get votes batch
-		results, err := rdb.LRange(ctx, "vote_queue", 0, int64(batchSize-1)).Result()
-		if err != nil {
-			return err
-		}
-		if len(results) == 0 {
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		upVotes := []models.Vote{}
-		downVotes := []models.Vote{}
-
-		// Parse votes
-		for _, result := range results {
-			var voteQueue VoteQueue
-			if err := json.Unmarshal([]byte(result), &voteQueue); err != nil {
-				continue
-			}
-
-			vote := models.Vote{ID: voteQueue.ID, Timestamp: voteQueue.Timestamp}
-			if voteQueue.VoteType == "up" {
-				upVotes = append(upVotes, vote)
-			} else {
-				downVotes = append(downVotes, vote)
-			}
-		}
-
-		// Process votes
-		if len(upVotes) > 0 {
-			if err := couponRepo.BatchAddVotes(ctx, upVotes, "up"); err != nil {
-				return err
-			}
-		}
-		if len(downVotes) > 0 {
-			if err := couponRepo.BatchAddVotes(ctx, downVotes, "down"); err != nil {
-				return err
-			}
-		}
+// DeleteVote godoc
+// @Summary Withdraw a vote
+// @Description Withdraw the caller's vote on a coupon
+// @Tags votes
+// @Produce json
+// @Param id path string true "Coupon ID"
+// @Param X-Voter-Token header string false "Optional client token identifying the voter, must match the one used to vote"
+// @Success 200 {object} models.Success
+// @Failure 400 {object} models.ErrorResponse
+// @Router /coupons/vote/{id} [delete]
+func DeleteVote(c *fiber.Ctx, rdb *redis.Client, voterHashSalt string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id < 1 {
+		return errInvalidCouponID
+	}
 
-		// Remove processed votes
-		rdb.LTrim(ctx, "vote_queue", int64(len(results)), -1)
+	if err := EnqueueVote(c, rdb, int64(id), models.VoteWithdraw, VoterHash(c, voterHashSalt)); err != nil {
+		return err
 	}
+
+	return c.JSON(models.Success{
+		Message: "Vote withdrawal successfully added to queue",
+	})
 }
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/models/syrup"
+	"discountdb-api/internal/repositories"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"log"
+	"strings"
+)
+
+// syrupPrefix is the path of the Syrup endpoints, which use their own error format
+const syrupPrefix = "/api/v1/syrup"
+
+// ErrorHandler turns errors returned by handlers into JSON responses.
+// Repository errors are mapped to status codes by their kind, any other error is logged and reported as 500.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	status, message := errorStatus(err)
+	if status == fiber.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+	}
+
+	c.Status(status)
+
+	if strings.HasPrefix(c.Path(), syrupPrefix) {
+		return c.JSON(syrup.ErrorResponse{
+			Error:   syrupErrorCode(err, status),
+			Message: message,
+		})
+	}
+
+	var duplicate *repositories.DuplicateCouponError
+	if errors.As(err, &duplicate) {
+		return c.JSON(models.DuplicateCouponResponse{
+			Message:    message,
+			ExistingID: duplicate.ExistingID,
+		})
+	}
+
+	return c.JSON(models.ErrorResponse{Message: message})
+}
+
+// errorStatus returns the status code of err and the message shown to the client
+func errorStatus(err error) (int, string) {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code, fiberErr.Message
+	}
+
+	var status int
+	switch {
+	case errors.Is(err, repositories.ErrValidation):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, repositories.ErrForbidden):
+		status = fiber.StatusForbidden
+	case errors.Is(err, repositories.ErrNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, repositories.ErrConflict):
+		status = fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError, "Internal server error"
+	}
+
+	// Only typed errors carry messages meant for clients
+	var typed *repositories.Error
+	var validation *repositories.ValidationError
+	var duplicate *repositories.DuplicateCouponError
+	switch {
+	case errors.As(err, &validation):
+		return status, validation.Message
+	case errors.As(err, &typed):
+		return status, typed.Message
+	case errors.As(err, &duplicate):
+		return status, "Coupon already exists"
+	default:
+		return status, utils.StatusMessage(status)
+	}
+}
+
+// syrupErrorCode returns the error code of the Syrup error format, e.g. InvalidLimit or NotFound
+func syrupErrorCode(err error, status int) string {
+	var validation *repositories.ValidationError
+	if errors.As(err, &validation) && validation.Field != "" {
+		code := "Invalid"
+		for _, part := range strings.Split(validation.Field, "_") {
+			if part == "id" {
+				code += "ID"
+			} else if part != "" {
+				code += strings.ToUpper(part[:1]) + part[1:]
+			}
+		}
+		return code
+	}
+
+	return strings.ReplaceAll(utils.StatusMessage(status), " ", "")
+}
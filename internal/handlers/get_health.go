@@ -1,19 +1,34 @@
 package handlers
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"discountdb-api/internal/middleware"
+	"discountdb-api/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
 
 // HealthCheck godoc
 // @Summary Health check endpoint
-// @Description Get API health status
+// @Description Get API health status. The status is degraded while the rate limiters cannot reach Redis,
+// @Description the rate limiter mode is then the configured failure policy instead of redis.
 // @Tags health
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.HealthCheckResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /health [get]
-func HealthCheck(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
-		"status":  "ok",
-		"version": "1.0",
-	})
+func HealthCheck(c *fiber.Ctx, breaker *middleware.CircuitBreaker, failurePolicy string) error {
+	response := models.HealthCheckResponse{
+		Status:  "ok",
+		Version: "1.0",
+		RateLimiter: models.RateLimiterHealthInfo{
+			Mode:    "redis",
+			Breaker: breaker.State(),
+		},
+	}
+	if response.RateLimiter.Breaker != middleware.BreakerClosed {
+		response.Status = "degraded"
+		response.RateLimiter.Mode = failurePolicy
+	}
+
+	return c.JSON(response)
 }
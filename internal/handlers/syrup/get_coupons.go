@@ -1,11 +1,11 @@
 package syrup
 
 import (
+	"discountdb-api/internal/cache"
 	"discountdb-api/internal/handlers/coupons"
 	"discountdb-api/internal/models/syrup"
 	"discountdb-api/internal/repositories"
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
 	"strconv"
 )
 
@@ -28,7 +28,7 @@ import (
 // @Header 429 {integer} X-RateLimit-RetryAfter "Time to wait before retrying (seconds)"
 // @Failure 500 {object} syrup.ErrorResponse "Internal Server Error"
 // @Router /syrup/coupons [get]
-func GetCoupons(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) error {
+func GetCoupons(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
 	params := repositories.SearchParams{
 		Limit:  20,
 		Offset: 0,
@@ -40,31 +40,19 @@ func GetCoupons(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *
 	params.SearchString = ctx.Query("domain")
 	params.SortBy = repositories.SortByHighScore
 
+	// Never serve coupons outside their validity window
+	params.Active = repositories.ValidityActive
+
 	if limitStr := ctx.Query("limitStr"); limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
 		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(
-				syrup.ErrorResponse{
-					Error:   "InvalidLimit",
-					Message: "Invalid limit value",
-				},
-			)
+			return &repositories.ValidationError{Field: "limit", Message: "Invalid limit value"}
 		}
 		if limit < 1 {
-			return ctx.Status(fiber.StatusBadRequest).JSON(
-				syrup.ErrorResponse{
-					Error:   "InvalidLimit",
-					Message: "Limit must be greater than 0",
-				},
-			)
+			return &repositories.ValidationError{Field: "limit", Message: "Limit must be greater than 0"}
 		}
 		if limit > 100 {
-			return ctx.Status(fiber.StatusBadRequest).JSON(
-				syrup.ErrorResponse{
-					Error:   "InvalidLimit",
-					Message: "Limit must be less than or equal to 100",
-				},
-			)
+			return &repositories.ValidationError{Field: "limit", Message: "Limit must be less than or equal to 100"}
 		}
 		params.Limit = limit
 	}
@@ -72,26 +60,16 @@ func GetCoupons(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *
 	if offsetStr := ctx.Query("offset"); offsetStr != "" {
 		offset, err := strconv.Atoi(offsetStr)
 		if err != nil {
-			return ctx.Status(fiber.StatusBadRequest).JSON(
-				syrup.ErrorResponse{
-					Error:   "InvalidOffset",
-					Message: "Invalid offset value",
-				},
-			)
+			return &repositories.ValidationError{Field: "offset", Message: "Invalid offset value"}
 		}
 		if offset < 0 {
-			return ctx.Status(fiber.StatusBadRequest).JSON(
-				syrup.ErrorResponse{
-					Error:   "InvalidOffset",
-					Message: "Offset must be greater than or equal to 0",
-				},
-			)
+			return &repositories.ValidationError{Field: "offset", Message: "Offset must be greater than or equal to 0"}
 		}
 		params.Offset = offset
 	}
 
 	// Search for coupons
-	response, err := coupons.SearchCoupons(params, ctx, couponRepo, rdb)
+	response, err := coupons.SearchCoupons(params, ctx, couponRepo, store)
 	if err != nil {
 		return err
 	}
@@ -99,8 +77,9 @@ func GetCoupons(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *
 	// Remap response to syrup.CouponList
 	merchantName := "N/A"
 
-	couponList := syrup.CouponList{
-		Total: response.Total,
+	couponList := syrup.CouponList{}
+	if response.Total != nil {
+		couponList.Total = int(*response.Total)
 	}
 
 	for _, coupon := range response.Data {
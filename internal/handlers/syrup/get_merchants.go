@@ -1,11 +1,11 @@
 package syrup
 
 import (
+	"discountdb-api/internal/cache"
 	"discountdb-api/internal/handlers/coupons"
 	"discountdb-api/internal/models/syrup"
 	"discountdb-api/internal/repositories"
 	"github.com/gofiber/fiber/v2"
-	"github.com/redis/go-redis/v9"
 )
 
 // GetMerchants godoc
@@ -24,8 +24,8 @@ import (
 // @Header 429 {integer} X-RateLimit-RetryAfter "Time to wait before retrying (seconds)"
 // @Failure 500 {object} syrup.ErrorResponse "Internal Server Error"
 // @Router /syrup/merchants [get]
-func GetMerchants(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, rdb *redis.Client) error {
-	merchants, err := coupons.GetMerchantsResponse(ctx, couponRepo, rdb)
+func GetMerchants(ctx *fiber.Ctx, couponRepo *repositories.CouponRepository, store *cache.Store) error {
+	merchants, err := coupons.GetMerchantsResponse(ctx, couponRepo, store)
 
 	if err != nil {
 		return err
@@ -1,6 +1,7 @@
 package syrup
 
 import (
+	"discountdb-api/internal/models"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 )
@@ -22,6 +23,6 @@ import (
 // @Header 429 {integer} X-RateLimit-RetryAfter "Time to wait before retrying (seconds)"
 // @Failure 500 {object} syrup.ErrorResponse "Internal Server Error"
 // @Router /syrup/coupons/invalid/{id} [post]
-func PostCouponInvalid(ctx *fiber.Ctx, rdb *redis.Client) error {
-	return PostCouponVote(ctx, rdb, "down")
+func PostCouponInvalid(ctx *fiber.Ctx, rdb *redis.Client, voterHashSalt string) error {
+	return PostCouponVote(ctx, rdb, voterHashSalt, models.VoteDown)
 }
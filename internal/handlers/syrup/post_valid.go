@@ -4,42 +4,19 @@ import (
 	"discountdb-api/internal/handlers/coupons"
 	"discountdb-api/internal/models"
 	"discountdb-api/internal/models/syrup"
-	"encoding/json"
+	"discountdb-api/internal/repositories"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"strconv"
-	"time"
 )
 
-func PostCouponVote(ctx *fiber.Ctx, rdb *redis.Client, dir string) error {
-	vote := models.VoteBody{
-		Dir: dir,
+func PostCouponVote(ctx *fiber.Ctx, rdb *redis.Client, voterHashSalt string, dir string) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil || id < 1 {
+		return &repositories.ValidationError{Field: "id", Message: "Invalid coupon ID"}
 	}
 
-	if id, err := strconv.Atoi(ctx.Params("id")); err != nil || id < 1 {
-		return ctx.Status(fiber.StatusBadRequest).JSON(
-			syrup.ErrorResponse{
-				Error:   "InvalidID",
-				Message: "Invalid coupon ID",
-			},
-		)
-	} else {
-		vote.ID = int64(id)
-	}
-
-	voteQueue := coupons.VoteQueue{
-		ID:        vote.ID,
-		Timestamp: time.Now(),
-		VoteType:  vote.Dir,
-	}
-
-	queueJSON, err := json.Marshal(voteQueue)
-	if err != nil {
-		return err
-	}
-
-	err = rdb.RPush(ctx.Context(), "vote_queue", queueJSON).Err()
-	if err != nil {
+	if err := coupons.EnqueueVote(ctx, rdb, int64(id), dir, coupons.VoterHash(ctx, voterHashSalt)); err != nil {
 		return err
 	}
 
@@ -65,6 +42,6 @@ func PostCouponVote(ctx *fiber.Ctx, rdb *redis.Client, dir string) error {
 // @Header 429 {integer} X-RateLimit-RetryAfter "Time to wait before retrying (seconds)"
 // @Failure 500 {object} syrup.ErrorResponse "Internal Server Error"
 // @Router /syrup/coupons/valid/{id} [post]
-func PostCouponValid(ctx *fiber.Ctx, rdb *redis.Client) error {
-	return PostCouponVote(ctx, rdb, "up")
+func PostCouponValid(ctx *fiber.Ctx, rdb *redis.Client, voterHashSalt string) error {
+	return PostCouponVote(ctx, rdb, voterHashSalt, models.VoteUp)
 }
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"discountdb-api/internal/repositories"
+	"log"
+)
+
+// JobHistoryCleaner removes old entries of the job run history
+type JobHistoryCleaner struct {
+	jobRepo       *repositories.JobRepository
+	retentionDays int
+}
+
+func NewJobHistoryCleaner(jobRepo *repositories.JobRepository, retentionDays int) *JobHistoryCleaner {
+	return &JobHistoryCleaner{
+		jobRepo:       jobRepo,
+		retentionDays: retentionDays,
+	}
+}
+
+// Run deletes the runs older than the retention period, it is meant to be run as a scheduled job
+func (c *JobHistoryCleaner) Run(ctx context.Context) error {
+	deleted, err := c.jobRepo.DeleteRunsBefore(ctx, c.retentionDays)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Printf("Deleted %d job runs older than %d days", deleted, c.retentionDays)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the times at which a job runs
+type Schedule interface {
+	// Next returns the first run time after t, the zero time if there is none
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// ParseSchedule parses a cron expression "minute hour day-of-month month day-of-week", evaluated in UTC.
+// Fields are *, numbers, ranges (1-5) and lists (1,15), each optionally with a step (*/10, 0-30/5).
+// Day of week is 0-6 with 0 and 7 both meaning Sunday. The shortcuts @hourly, @daily, @weekly, @monthly
+// and "@every <duration>" are supported as well. @every runs are aligned to multiples of the duration,
+// so all replicas agree on them.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("invalid schedule %q: @every needs a duration of at least 1s", spec)
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	expr := spec
+	switch spec {
+	case "@hourly":
+		expr = "0 * * * *"
+	case "@daily", "@midnight":
+		expr = "0 0 * * *"
+	case "@weekly":
+		expr = "0 0 * * 0"
+	case "@monthly":
+		expr = "0 0 1 * *"
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+
+	s := cronSchedule{spec: spec}
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", spec, err)
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAll = fields[2] == "*"
+	s.dowAll = fields[4] == "*"
+
+	return s, nil
+}
+
+// MustParseSchedule is like ParseSchedule but panics if spec is invalid
+func MustParseSchedule(spec string) Schedule {
+	s, err := ParseSchedule(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField returns the values of a cron field as a bit set
+func parseField(field string, first, last int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*":
+			lo, hi = first, last
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(from)
+			hi, err2 = strconv.Atoi(to)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			lo, hi = n, n
+			// "5/10" means from 5 to the end in steps of 10
+			if hasStep {
+				hi = last
+			}
+		}
+		if lo < first || hi > last || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", rangePart, first, last)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+type cronSchedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	domAll, dowAll                bool
+}
+
+func (s cronSchedule) String() string {
+	return s.spec
+}
+
+// dayMatches applies the cron rule that a day matches either restricted day field if both are restricted
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<t.Weekday()) != 0
+	if s.domAll || s.dowAll {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// Impossible dates like February 30th never match
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) String() string {
+	return "@every " + s.interval.String()
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.UTC().Truncate(s.interval).Add(s.interval)
+}
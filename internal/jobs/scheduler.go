@@ -0,0 +1,420 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Job is a task run on a schedule, or manually through the admin API
+type Job struct {
+	Name     string
+	Schedule Schedule
+
+	// Optional maximum duration of a run, 30 minutes by default
+	Timeout time.Duration
+
+	// Optional random delay added to every scheduled run, spreads the load of jobs with the same schedule
+	Jitter time.Duration
+
+	// Run does the work, it should return once ctx is cancelled
+	Run func(ctx context.Context) error
+}
+
+// DefaultJobTimeout is the timeout of jobs that do not set one
+const DefaultJobTimeout = 30 * time.Minute
+
+// workerRestartDelay is the pause before a failed worker is restarted
+const workerRestartDelay = 5 * time.Second
+
+type scheduledJob struct {
+	Job
+
+	mu      sync.Mutex
+	nextRun time.Time
+}
+
+type worker struct {
+	name string
+	run  func(ctx context.Context)
+
+	mu        sync.Mutex
+	running   bool
+	restarts  int
+	lastError string
+}
+
+// Scheduler runs jobs on their schedules and keeps workers running until it is stopped.
+//
+// Every replica of the API runs the same scheduler. A job only runs on one replica at a time, which is ensured
+// with a Postgres advisory lock, and every schedule slot runs once, which is ensured by the unique slot in the
+// run history. Workers on the other hand run on every replica.
+type Scheduler struct {
+	db       *sql.DB
+	jobRepo  *repositories.JobRepository
+	instance string
+
+	jobs    []*scheduledJob
+	byName  map[string]*scheduledJob
+	workers []*worker
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	started bool
+}
+
+func NewScheduler(db *sql.DB, jobRepo *repositories.JobRepository) *Scheduler {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		db:       db,
+		jobRepo:  jobRepo,
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		byName:   make(map[string]*scheduledJob),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Add registers a job, it panics if the job is invalid or its name is taken. Jobs must be added before Start.
+func (s *Scheduler) Add(job Job) {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		panic("job needs a name, a schedule and a run function")
+	}
+	if _, ok := s.byName[job.Name]; ok {
+		panic(fmt.Sprintf("job %s is added twice", job.Name))
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = DefaultJobTimeout
+	}
+
+	j := &scheduledJob{Job: job}
+	s.jobs = append(s.jobs, j)
+	s.byName[job.Name] = j
+}
+
+// AddWorker registers a long running function, which is restarted if it returns or panics before the
+// scheduler is stopped. Workers must be added before Start.
+func (s *Scheduler) AddWorker(name string, run func(ctx context.Context)) {
+	s.workers = append(s.workers, &worker{name: name, run: run})
+}
+
+// Start runs the jobs and workers in the background, calling it again has no effect
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started || s.ctx.Err() != nil {
+		return
+	}
+	s.started = true
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+	for _, w := range s.workers {
+		s.wg.Add(1)
+		go s.supervise(w)
+	}
+}
+
+// Stop cancels running jobs and workers and waits until they have returned or ctx is done.
+// It is safe to call whether or not the scheduler was started.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	// Under the lock, so Trigger cannot add a run while we wait
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs did not stop in time: %w", ctx.Err())
+	}
+}
+
+// loop runs a job at every slot of its schedule
+func (s *Scheduler) loop(j *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		slot := j.Schedule.Next(time.Now())
+		if slot.IsZero() {
+			log.Printf("Job %s has no more runs scheduled", j.Name)
+			return
+		}
+
+		j.mu.Lock()
+		j.nextRun = slot
+		j.mu.Unlock()
+
+		delay := time.Until(slot)
+		if j.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(j.Jitter)))
+		}
+		if !sleep(s.ctx, delay) {
+			return
+		}
+
+		run, release, err := s.begin(s.ctx, j, models.JobTriggerSchedule, &slot)
+		if err != nil {
+			log.Printf("Failed to start job %s: %v", j.Name, err)
+			continue
+		}
+		if run == nil {
+			// Another replica runs or ran this slot
+			continue
+		}
+		s.execute(j, run, release)
+	}
+}
+
+// Trigger starts a run of the named job in the background and returns it.
+// Returns ErrJobNotFound for unknown jobs and ErrJobRunning if the job is running on any replica.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*models.JobRun, error) {
+	j, ok := s.byName[name]
+	if !ok {
+		return nil, repositories.ErrJobNotFound
+	}
+
+	s.mu.Lock()
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("scheduler is stopped")
+	}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	run, release, err := s.begin(ctx, j, models.JobTriggerManual, nil)
+	if err != nil {
+		s.wg.Done()
+		return nil, err
+	}
+
+	go func() {
+		defer s.wg.Done()
+		s.execute(j, run, release)
+	}()
+
+	return run, nil
+}
+
+// begin takes the job's lock and records the start of a run. It returns a nil run if the slot was already run.
+// The returned function releases the lock and must be called once the run is over.
+func (s *Scheduler) begin(ctx context.Context, j *scheduledJob, trigger string, slot *time.Time) (*models.JobRun, func(), error) {
+	release, err := s.lock(ctx, j.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if release == nil {
+		if trigger == models.JobTriggerManual {
+			return nil, nil, repositories.ErrJobRunning
+		}
+		return nil, nil, nil
+	}
+
+	run := &models.JobRun{JobName: j.Name, Trigger: trigger, Instance: s.instance}
+	if slot != nil {
+		// Slots are stored in UTC, so replicas in different time zones agree on them
+		utc := slot.UTC()
+		run.ScheduledFor = &utc
+	}
+
+	started, err := s.jobRepo.StartRun(ctx, run)
+	if err != nil || !started {
+		release()
+		return nil, nil, err
+	}
+	return run, release, nil
+}
+
+// execute runs the job and records the result, the run must have been started with begin
+func (s *Scheduler) execute(j *scheduledJob, run *models.JobRun, release func()) {
+	defer release()
+
+	ctx, cancel := context.WithTimeout(s.ctx, j.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := call(ctx, j)
+	duration := time.Since(start)
+
+	message := ""
+	if err != nil {
+		message = err.Error()
+		log.Printf("Job %s failed after %s: %v", j.Name, duration.Round(time.Millisecond), err)
+	}
+
+	// The scheduler may be stopping, record the result anyway
+	ctx, cancel = context.WithTimeout(context.WithoutCancel(s.ctx), 10*time.Second)
+	defer cancel()
+	if err := s.jobRepo.FinishRun(ctx, run.ID, duration, message); err != nil {
+		log.Printf("Failed to record run of job %s: %v", j.Name, err)
+	}
+}
+
+// call runs the job, turning a panic into an error
+func call(ctx context.Context, j *scheduledJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v\n%s", j.Name, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.Run(ctx)
+}
+
+// lock takes the job's advisory lock on a dedicated connection without waiting.
+// It returns a nil function if another session holds the lock.
+func (s *Scheduler) lock(ctx context.Context, name string) (func(), error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "job:" + name
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, key); err != nil {
+			log.Printf("Failed to unlock job %s, dropping the connection: %v", name, err)
+			// Closing the session releases its locks
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
+
+// supervise runs a worker until the scheduler is stopped
+func (s *Scheduler) supervise(w *worker) {
+	defer s.wg.Done()
+
+	for s.ctx.Err() == nil {
+		w.mu.Lock()
+		w.running = true
+		w.mu.Unlock()
+
+		err := runWorker(s.ctx, w)
+
+		w.mu.Lock()
+		w.running = false
+		w.mu.Unlock()
+
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			err = fmt.Errorf("worker returned")
+		}
+		log.Printf("Worker %s stopped, restarting in %s: %v", w.name, workerRestartDelay, err)
+
+		w.mu.Lock()
+		w.restarts++
+		w.lastError = err.Error()
+		w.mu.Unlock()
+
+		if !sleep(s.ctx, workerRestartDelay) {
+			return
+		}
+	}
+}
+
+// runWorker runs the worker, turning a panic into an error
+func runWorker(ctx context.Context, w *worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Worker %s panicked: %v\n%s", w.name, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	w.run(ctx)
+	return nil
+}
+
+// Status returns the jobs with their latest runs and the workers of this replica
+func (s *Scheduler) Status(ctx context.Context, runs int) (*models.JobsResponse, error) {
+	response := &models.JobsResponse{
+		Jobs:    []models.JobStatus{},
+		Workers: []models.WorkerStatus{},
+	}
+
+	for _, j := range s.jobs {
+		history, err := s.jobRepo.Runs(ctx, j.Name, runs)
+		if err != nil {
+			return nil, err
+		}
+
+		status := models.JobStatus{
+			Name:           j.Name,
+			Schedule:       j.Schedule.String(),
+			TimeoutSeconds: int64(j.Timeout / time.Second),
+			Runs:           history,
+		}
+		j.mu.Lock()
+		if !j.nextRun.IsZero() {
+			next := j.nextRun
+			status.NextRunAt = &next
+		}
+		j.mu.Unlock()
+
+		response.Jobs = append(response.Jobs, status)
+	}
+
+	for _, w := range s.workers {
+		w.mu.Lock()
+		response.Workers = append(response.Workers, models.WorkerStatus{
+			Name:      w.name,
+			Running:   w.running,
+			Restarts:  w.restarts,
+			LastError: w.lastError,
+		})
+		w.mu.Unlock()
+	}
+
+	return response, nil
+}
+
+// sleep waits for d and reports whether ctx is still active
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -2,86 +2,69 @@ package jobs
 
 import (
 	"context"
-	"database/sql"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/repositories"
 	"log"
 	"time"
 )
 
+// ScoreUpdater recomputes the scores that changed because votes or coupons got older.
+// Each coupon is scheduled for its next decay step boundary, coupons in between are skipped.
 type ScoreUpdater struct {
-	db        *sql.DB
+	scoreRepo *repositories.ScoreRepository
+	store     *cache.Store
 	batchSize int
-	interval  time.Duration
-	done      chan bool
+	pause     time.Duration
 }
 
-func NewScoreUpdater(db *sql.DB, batchSize int, interval time.Duration) *ScoreUpdater {
+func NewScoreUpdater(scoreRepo *repositories.ScoreRepository, store *cache.Store, batchSize int, pause time.Duration) *ScoreUpdater {
 	return &ScoreUpdater{
-		db:        db,
+		scoreRepo: scoreRepo,
+		store:     store,
 		batchSize: batchSize,
-		interval:  interval,
-		done:      make(chan bool),
+		pause:     pause,
 	}
 }
 
-func (s *ScoreUpdater) updateScores() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-
-	// Get total count of coupons that need updating
-	var totalToUpdate int
-	err := s.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) 
-		FROM coupons 
-		WHERE last_score_update IS NULL 
-		OR last_score_update < CURRENT_TIMESTAMP - INTERVAL '1 hour'
-	`).Scan(&totalToUpdate)
-	if err != nil {
-		return err
-	}
-
-	// No coupons need updating
-	if totalToUpdate == 0 {
-		return nil
+// RescoredTags returns the cache tags of the recomputed coupons, see cache.ScoreTags
+func RescoredTags(coupons []repositories.RescoredCoupon) []string {
+	merchantNames := make(map[int64]string, len(coupons))
+	for _, coupon := range coupons {
+		merchantNames[coupon.ID] = coupon.MerchantName
 	}
+	return cache.ScoreTags(merchantNames)
+}
 
-	// Calculate number of batches needed
-	batches := (totalToUpdate + s.batchSize - 1) / s.batchSize
-
-	// Process all batches
-	for i := 0; i < batches; i++ {
-		_, err := s.db.ExecContext(ctx, "SELECT update_materialized_scores_batch($1)", s.batchSize)
+// Run recomputes the scores that are due, it is meant to be run as a scheduled job
+func (s *ScoreUpdater) Run(ctx context.Context) error {
+	// Process batches until no more coupons are due
+	updated := 0
+	for {
+		coupons, err := s.scoreRepo.UpdateDueBatch(ctx, s.batchSize)
 		if err != nil {
 			return err
 		}
+		updated += len(coupons)
 
-		// Small sleep between batches to reduce database load
-		time.Sleep(100 * time.Millisecond)
-	}
+		// Cached scores of these coupons are outdated now
+		s.store.Invalidate(ctx, RescoredTags(coupons)...)
 
-	// Log success
-	log.Printf("Updated scores for %d coupons", totalToUpdate)
+		if len(coupons) < s.batchSize {
+			break
+		}
 
-	return nil
-}
+		// Pause between batches to reduce database load
+		if !sleep(ctx, s.pause) {
+			return ctx.Err()
+		}
+	}
 
-func (s *ScoreUpdater) Start() {
-	go func() {
-		ticker := time.NewTicker(s.interval)
-		defer ticker.Stop()
+	skipped, err := s.scoreRepo.CountNotDue(ctx)
+	if err != nil {
+		return err
+	}
 
-		for {
-			select {
-			case <-ticker.C:
-				if err := s.updateScores(); err != nil {
-					log.Printf("Error updating scores: %v", err)
-				}
-			case <-s.done:
-				return
-			}
-		}
-	}()
-}
+	log.Printf("Updated scores for %d coupons, skipped %d whose scores did not change", updated, skipped)
 
-func (s *ScoreUpdater) Stop() {
-	s.done <- true
+	return nil
 }
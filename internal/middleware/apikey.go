@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Request headers carrying the API key, the Syrup header is accepted on every endpoint
+const (
+	APIKeyHeader      = "X-API-Key"
+	SyrupAPIKeyHeader = "X-Syrup-API-Key"
+)
+
+// apiKeyLocal is the fiber.Ctx local holding the *models.APIKey of the request
+const apiKeyLocal = "apiKey"
+
+var (
+	errInvalidAPIKey  = fiber.NewError(fiber.StatusUnauthorized, "Invalid API key")
+	errAPIKeyRequired = fiber.NewError(fiber.StatusUnauthorized, "API key is required")
+)
+
+// NewAPIKeyAuth identifies the API key sent with a request and attaches it to the context, see APIKeyFromCtx.
+// Requests without a key stay anonymous, requests with an unknown or revoked key are rejected.
+// Lookups are cached, revoking a key has to invalidate cache.APIKeyTag.
+func NewAPIKeyAuth(apiKeyRepo *repositories.APIKeyRepository, store *cache.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret := c.Get(APIKeyHeader)
+		if secret == "" {
+			secret = c.Get(SyrupAPIKeyHeader)
+		}
+		if secret == "" {
+			return c.Next()
+		}
+
+		keyHash := repositories.HashAPIKey(secret)
+		key, err := cache.GetOrLoad(c.Context(), store, cache.Key("apikey", keyHash), func(ctx context.Context) (*models.APIKey, []string, error) {
+			key, err := apiKeyRepo.GetByHash(ctx, keyHash)
+			if errors.Is(err, repositories.ErrNotFound) {
+				return nil, nil, cache.ErrNotFound
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			return key, []string{cache.APIKeyTag(key.ID)}, nil
+		})
+		if errors.Is(err, cache.ErrNotFound) {
+			return errInvalidAPIKey
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up API key: %w", err)
+		}
+
+		c.Locals(apiKeyLocal, key)
+		return c.Next()
+	}
+}
+
+// APIKeyFromCtx returns the API key of the request, or nil for anonymous requests
+func APIKeyFromCtx(c *fiber.Ctx) *models.APIKey {
+	key, _ := c.Locals(apiKeyLocal).(*models.APIKey)
+	return key
+}
+
+// RequireScope only lets requests through whose API key grants scope
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := APIKeyFromCtx(c)
+		if key == nil {
+			return errAPIKeyRequired
+		}
+		if !key.HasScope(scope) {
+			return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("API key lacks the %s scope", scope))
+		}
+		return c.Next()
+	}
+}
+
+// CheckScope lets anonymous requests through, but rejects requests whose API key does not grant scope
+func CheckScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := APIKeyFromCtx(c); key != nil && !key.HasScope(scope) {
+			return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("API key lacks the %s scope", scope))
+		}
+		return c.Next()
+	}
+}
+
+// ClientID identifies the client of a request for rate limiting, its API key or otherwise its IP address
+func ClientID(c *fiber.Ctx) string {
+	if key := APIKeyFromCtx(c); key != nil {
+		return fmt.Sprintf("key:%d", key.ID)
+	}
+	return c.IP()
+}
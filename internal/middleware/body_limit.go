@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	"io"
+)
+
+var errBodyTooLarge = fiber.NewError(fiber.StatusRequestEntityTooLarge, "Request body is too large")
+
+// NewBodyLimit enforces the app's body limit while request bodies are streamed.
+//
+// With streaming enabled, bodies over the limit are not rejected by the server but handed to the handler
+// as a stream, which c.Body and c.BodyParser read into memory completely. This rejects them with 413,
+// except for requests for which skip returns true, whose handlers read the stream with their own limit.
+func NewBodyLimit(skip func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if skip != nil && skip(c) {
+			return c.Next()
+		}
+
+		limit := c.App().Config().BodyLimit
+		if limit <= 0 {
+			limit = fiber.DefaultBodyLimit
+		}
+
+		length := c.Request().Header.ContentLength()
+		if length > limit {
+			// The rest of the body is still unread, the connection cannot be reused
+			c.Context().SetConnectionClose()
+			return errBodyTooLarge
+		}
+
+		// Chunked bodies have no length, read them up to the limit
+		if stream := c.Context().RequestBodyStream(); length < 0 && stream != nil {
+			var body bytes.Buffer
+			if _, err := io.Copy(&body, io.LimitReader(stream, int64(limit)+1)); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Failed to read request body")
+			}
+			if body.Len() > limit {
+				c.Context().SetConnectionClose()
+				return errBodyTooLarge
+			}
+			c.Request().SetBody(body.Bytes())
+		}
+
+		return c.Next()
+	}
+}
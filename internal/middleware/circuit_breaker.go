@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // calls go through
+	BreakerOpen     = "open"      // calls are skipped until the cooldown has passed
+	BreakerHalfOpen = "half_open" // a single probe call decides whether to close or open again
+)
+
+// CircuitBreaker stops calling a failing dependency. After Threshold consecutive failures it opens for Cooldown,
+// then lets one probe call through. It is safe for concurrent use.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker, name is used in log messages
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{name: name, threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// Allow reports whether a call may be made, every allowed call must be followed by Success or Failure
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	default:
+		// The probe is still running
+		return false
+	}
+}
+
+// Success records a successful call and closes the breaker
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerClosed {
+		log.Printf("%s recovered, circuit closed", b.name)
+	}
+	b.state = BreakerClosed
+	b.failures = 0
+}
+
+// Failure records a failed call and opens the breaker once the threshold is reached or the probe failed
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		if b.state == BreakerClosed {
+			log.Printf("%s failed %d times, circuit open for %s: %v", b.name, b.failures, b.cooldown, err)
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the current state, an open breaker whose cooldown has passed is reported as half open
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
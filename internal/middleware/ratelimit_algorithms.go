@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rate limiting algorithms, see RateLimiterConfig.Algorithm
+const (
+	// AlgorithmSlidingWindow allows Max requests in any period of Window, it stores the time of every request
+	AlgorithmSlidingWindow = "sliding_window"
+	// AlgorithmTokenBucket allows bursts of Max requests and refills Max tokens per Window at a steady rate
+	AlgorithmTokenBucket = "token_bucket"
+	// AlgorithmFixedWindow allows Max requests per Window, counted from the first request of the window
+	AlgorithmFixedWindow = "fixed_window"
+)
+
+// The scripts take the key, the maximum and the window in milliseconds and return whether the request is allowed,
+// the remaining requests, the milliseconds until the limit is fully reset and the milliseconds until a request
+// is allowed again. They use the Redis clock, so all instances of the API agree on the time.
+
+// slidingWindowScript keeps the request times in a sorted set, ARGV[3] is a unique member for this request
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count < limit then
+    redis.call('ZADD', KEYS[1], now, now .. ':' .. ARGV[3])
+    redis.call('PEXPIRE', KEYS[1], window)
+    count = count + 1
+    allowed = 1
+end
+
+local oldest = tonumber(redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')[2]) or now
+local newest = tonumber(redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')[2]) or now
+local retry = 0
+if allowed == 0 then
+    retry = oldest + window - now
+end
+return {allowed, math.max(limit - count, 0), newest + window - now, retry}
+`)
+
+// tokenBucketScript stores the tokens left and the time they were counted in a hash
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local rate = limit / window
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+    tokens = limit
+    ts = now
+end
+tokens = math.min(limit, tokens + math.max(now - ts, 0) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+    tokens = tokens - 1
+    allowed = 1
+else
+    retry = math.ceil((1 - tokens) / rate)
+end
+
+local reset = math.ceil((limit - tokens) / rate)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// fixedWindowScript counts the requests of a window that starts with its first request
+var fixedWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+    redis.call('PEXPIRE', KEYS[1], window)
+    ttl = window
+end
+
+if count > limit then
+    return {0, 0, ttl, ttl}
+end
+return {1, limit - count, ttl, 0}
+`)
+
+// limitResult is the outcome of counting a request
+type limitResult struct {
+	allowed    bool
+	remaining  int64
+	reset      time.Duration // until the full limit is available again
+	retryAfter time.Duration // until the next request is allowed, 0 if this one was
+}
+
+// take counts a request against the limit of key with the configured algorithm
+func take(ctx context.Context, rdb *redis.Client, algorithm, key string, max int, window time.Duration) (limitResult, error) {
+	args := []interface{}{max, window.Milliseconds()}
+
+	var script *redis.Script
+	switch algorithm {
+	case AlgorithmTokenBucket:
+		script = tokenBucketScript
+	case AlgorithmFixedWindow:
+		script = fixedWindowScript
+	default:
+		script = slidingWindowScript
+
+		// Requests in the same millisecond need distinct members
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return limitResult{}, err
+		}
+		args = append(args, hex.EncodeToString(buf))
+	}
+
+	values, err := script.Run(ctx, rdb, []string{key}, args...).Int64Slice()
+	if err != nil {
+		return limitResult{}, err
+	}
+	if len(values) != 4 {
+		return limitResult{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return limitResult{
+		allowed:    values[0] == 1,
+		remaining:  values[1],
+		reset:      time.Duration(values[2]) * time.Millisecond,
+		retryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// localLimiter is the per-process fallback used while Redis is unavailable. It is a token bucket for every
+// algorithm, and each instance of the API counts on its own, so the limits are only approximated.
+type localLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*localBucket
+	lastSweep time.Time
+}
+
+type localBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket is full again and can be forgotten
+}
+
+func newLocalLimiter() *localLimiter {
+	return &localLimiter{buckets: make(map[string]*localBucket), lastSweep: time.Now()}
+}
+
+func (l *localLimiter) take(key string, limit int, window time.Duration) limitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now, window)
+
+	rate := float64(limit) / float64(window) // tokens per nanosecond
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &localBucket{tokens: float64(limit), updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(float64(limit), bucket.tokens+float64(now.Sub(bucket.updated))*rate)
+	bucket.updated = now
+
+	result := limitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = time.Duration(math.Ceil((1 - bucket.tokens) / rate))
+	}
+
+	result.remaining = int64(bucket.tokens)
+	result.reset = time.Duration(math.Ceil((float64(limit) - bucket.tokens) / rate))
+	bucket.full = now.Add(result.reset)
+	return result
+}
+
+// sweep forgets full buckets at most once per window, so idle clients do not accumulate
+func (l *localLimiter) sweep(now time.Time, window time.Duration) {
+	if now.Sub(l.lastSweep) < window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if !bucket.full.After(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
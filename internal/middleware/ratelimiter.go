@@ -2,25 +2,64 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 )
 
+// Failure policies, what a rate limiter does while Redis is unavailable
+const (
+	FailOpen   = "fail_open"   // let every request through
+	FailClosed = "fail_closed" // reject every request with 503
+	FailLocal  = "local"       // limit with a per-process in-memory limiter
+)
+
+var (
+	errCircuitOpen            = errors.New("rate limiter circuit open")
+	errRateLimiterUnavailable = fiber.NewError(fiber.StatusServiceUnavailable, "Rate limiter unavailable, try again later")
+)
+
+// IsFailurePolicy reports whether policy is one of the failure policies
+func IsFailurePolicy(policy string) bool {
+	switch policy {
+	case FailOpen, FailClosed, FailLocal:
+		return true
+	}
+	return false
+}
+
 // RateLimiterConfig holds the configuration for the rate limiter middleware
 type RateLimiterConfig struct {
 	// Maximum number of requests allowed within the window
 	Max int
 
-	// Duration of the sliding window
+	// Optional name of the limit, API keys with a quota of this name get that maximum instead of Max
+	Name string
+
+	// Duration of the window, for the token bucket the time in which Max tokens are refilled
 	Window time.Duration
 
+	// Optional algorithm: AlgorithmSlidingWindow (default), AlgorithmTokenBucket or AlgorithmFixedWindow
+	Algorithm string
+
 	// Redis client instance
 	Redis *redis.Client
 
+	// Optional timeout of a single Redis call
+	Timeout time.Duration
+
+	// Optional behaviour while Redis fails: FailLocal (default), FailOpen or FailClosed
+	FailurePolicy string
+
+	// Optional circuit breaker guarding Redis, share one between all limiters using the same Redis.
+	// By default every limiter has its own.
+	Breaker *CircuitBreaker
+
 	// Optional prefix for Redis keys
 	KeyPrefix string
 
@@ -33,17 +72,18 @@ type RateLimiterConfig struct {
 
 // ConfigDefault provides default configuration
 var ConfigDefault = RateLimiterConfig{
-	Max:       60,
-	Window:    time.Minute,
-	KeyPrefix: "ratelimit",
+	Max:           60,
+	Window:        time.Minute,
+	Algorithm:     AlgorithmSlidingWindow,
+	Timeout:       500 * time.Millisecond,
+	FailurePolicy: FailLocal,
+	KeyPrefix:     "ratelimit",
 	LimitExceededHandler: func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
 			"error": "Too many requests",
 		})
 	},
-	KeyFunc: func(c *fiber.Ctx) string {
-		return c.IP()
-	},
+	KeyFunc: ClientID,
 }
 
 // validateConfig ensures the configuration is valid
@@ -57,6 +97,14 @@ func validateConfig(cfg *RateLimiterConfig) error {
 	if cfg.Window > 24*time.Hour {
 		return fmt.Errorf("window must not exceed 24 hours")
 	}
+	switch cfg.Algorithm {
+	case AlgorithmSlidingWindow, AlgorithmTokenBucket, AlgorithmFixedWindow:
+	default:
+		return fmt.Errorf("unknown algorithm %q", cfg.Algorithm)
+	}
+	if !IsFailurePolicy(cfg.FailurePolicy) {
+		return fmt.Errorf("unknown failure policy %q", cfg.FailurePolicy)
+	}
 	return nil
 }
 
@@ -71,6 +119,21 @@ func configDefault(config ...RateLimiterConfig) (RateLimiterConfig, error) {
 		if config[0].Window != 0 {
 			cfg.Window = config[0].Window
 		}
+		if config[0].Algorithm != "" {
+			cfg.Algorithm = config[0].Algorithm
+		}
+		if config[0].Timeout != 0 {
+			cfg.Timeout = config[0].Timeout
+		}
+		if config[0].FailurePolicy != "" {
+			cfg.FailurePolicy = config[0].FailurePolicy
+		}
+		if config[0].Breaker != nil {
+			cfg.Breaker = config[0].Breaker
+		}
+		if config[0].Name != "" {
+			cfg.Name = config[0].Name
+		}
 		if config[0].KeyPrefix != "" {
 			cfg.KeyPrefix = config[0].KeyPrefix
 		}
@@ -92,13 +155,27 @@ func configDefault(config ...RateLimiterConfig) (RateLimiterConfig, error) {
 	return cfg, nil
 }
 
-// getRemainingTime calculates the time remaining until the rate limit resets
-func getRemainingTime(ctx context.Context, redis *redis.Client, key string) (int64, error) {
-	ttl, err := redis.TTL(ctx, key).Result()
+// seconds rounds d up to whole seconds for the rate limit headers
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// takeGuarded counts the request in Redis unless the circuit breaker is open
+func takeGuarded(c *fiber.Ctx, cfg RateLimiterConfig, key string, limit int) (limitResult, error) {
+	if !cfg.Breaker.Allow() {
+		return limitResult{}, errCircuitOpen
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), cfg.Timeout)
+	defer cancel()
+
+	result, err := take(ctx, cfg.Redis, cfg.Algorithm, key, limit, cfg.Window)
 	if err != nil {
-		return 0, err
+		cfg.Breaker.Failure(err)
+		return limitResult{}, err
 	}
-	return int64(math.Ceil(ttl.Seconds())), nil
+	cfg.Breaker.Success()
+	return result, nil
 }
 
 // NewRateLimiter creates a new rate limiter middleware
@@ -112,55 +189,51 @@ func NewRateLimiter(config ...RateLimiterConfig) fiber.Handler {
 		panic("Redis client is required for rate limiter")
 	}
 
+	if cfg.Breaker == nil {
+		cfg.Breaker = NewCircuitBreaker("Rate limiter Redis", 5, 30*time.Second)
+	}
+	local := newLocalLimiter()
+
 	// Return the middleware handler
 	return func(c *fiber.Ctx) error {
-		// Use request context for proper cancellation
-		ctx := c.Context()
-
-		// Generate Redis key with proper separator
-		key := fmt.Sprintf("%s:%s", cfg.KeyPrefix, cfg.KeyFunc(c))
-
-		// Use Redis MULTI/EXEC for atomic operations
-		pipe := cfg.Redis.TxPipeline()
-
-		// Increment counter and set expiration in single transaction
-		incr := pipe.Incr(ctx, key)
-		pipe.Expire(ctx, key, cfg.Window)
-
-		// Execute transaction
-		_, err := pipe.Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("rate limiter redis error: %w", err)
-		}
-
-		// Get the current count
-		count := incr.Val()
-
-		// If this is the first request, ensure the key expires
-		if count == 1 {
-			cfg.Redis.Expire(ctx, key, cfg.Window)
-		}
-
-		// Calculate remaining attempts
-		remaining := int64(cfg.Max) - count
-		if remaining < 0 {
-			remaining = 0
+		// Generate Redis key with proper separator, separate per algorithm as they store different types
+		key := fmt.Sprintf("%s:%s:%s", cfg.KeyPrefix, cfg.Algorithm, cfg.KeyFunc(c))
+
+		// API keys may have their own quota
+		limit := cfg.Max
+		if apiKey := APIKeyFromCtx(c); apiKey != nil && cfg.Name != "" {
+			if quota, ok := apiKey.Quotas[cfg.Name]; ok {
+				limit = quota
+			}
 		}
 
-		// Get remaining time until reset
-		remainingTime, err := getRemainingTime(ctx, cfg.Redis, key)
+		result, err := takeGuarded(c, cfg, key, limit)
 		if err != nil {
-			return fmt.Errorf("error getting remaining time: %w", err)
+			switch cfg.FailurePolicy {
+			case FailOpen:
+				return c.Next()
+			case FailClosed:
+				c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds(cfg.Breaker.cooldown), 10))
+				return errRateLimiterUnavailable
+			default:
+				result = local.take(key, limit, cfg.Window)
+			}
 		}
 
-		// Set rate limit headers
-		c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", cfg.Max))
-		c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
-		c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", remainingTime))
+		// Set the standard rate limit headers, and the X- headers older clients read
+		c.Set("RateLimit-Limit", strconv.Itoa(limit))
+		c.Set("RateLimit-Remaining", strconv.FormatInt(result.remaining, 10))
+		c.Set("RateLimit-Reset", strconv.FormatInt(seconds(result.reset), 10))
+		c.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit, seconds(cfg.Window)))
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Set("X-RateLimit-Remaining", strconv.FormatInt(result.remaining, 10))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(seconds(result.reset), 10))
 
 		// Check if limit is exceeded
-		if count > int64(cfg.Max) {
-			c.Set("X-RateLimit-RetryAfter", fmt.Sprintf("%d", remainingTime))
+		if !result.allowed {
+			retryAfter := strconv.FormatInt(max(seconds(result.retryAfter), 1), 10)
+			c.Set(fiber.HeaderRetryAfter, retryAfter)
+			c.Set("X-RateLimit-RetryAfter", retryAfter)
 			return cfg.LimitExceededHandler(c)
 		}
 
@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"discountdb-api/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testStart is the time of the miniredis clock, which the scripts read with TIME
+var testStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestRedis starts a miniredis server with its clock set to testStart
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	mr.SetTime(testStart)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+// newTestApp returns an app answering GET / behind a rate limiter with cfg, clients are told apart by X-Client
+func newTestApp(cfg RateLimiterConfig) *fiber.App {
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = func(c *fiber.Ctx) string {
+			return c.Get("X-Client", "client")
+		}
+	}
+
+	app := fiber.New()
+	app.Get("/", NewRateLimiter(cfg), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func doRequest(t *testing.T, app *fiber.App, client string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("X-Client", client)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return resp
+}
+
+func expectStatus(t *testing.T, resp *http.Response, status int) {
+	t.Helper()
+
+	if resp.StatusCode != status {
+		t.Fatalf("expected status %d, got %d", status, resp.StatusCode)
+	}
+}
+
+func expectHeader(t *testing.T, resp *http.Response, name, value string) {
+	t.Helper()
+
+	if got := resp.Header.Get(name); got != value {
+		t.Fatalf("expected header %s to be %q, got %q", name, value, got)
+	}
+}
+
+func TestRateLimiterAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmSlidingWindow, AlgorithmTokenBucket, AlgorithmFixedWindow} {
+		t.Run(algorithm, func(t *testing.T) {
+			_, rdb := newTestRedis(t)
+			app := newTestApp(RateLimiterConfig{
+				Max:       3,
+				Window:    time.Minute,
+				Algorithm: algorithm,
+				Redis:     rdb,
+			})
+
+			for i := 1; i <= 3; i++ {
+				resp := doRequest(t, app, "a")
+				expectStatus(t, resp, fiber.StatusOK)
+				remaining := strconv.Itoa(3 - i)
+				expectHeader(t, resp, "RateLimit-Limit", "3")
+				expectHeader(t, resp, "RateLimit-Remaining", remaining)
+				expectHeader(t, resp, "RateLimit-Policy", "3;w=60")
+				expectHeader(t, resp, "X-RateLimit-Limit", "3")
+				expectHeader(t, resp, "X-RateLimit-Remaining", remaining)
+				expectHeader(t, resp, "Retry-After", "")
+			}
+
+			resp := doRequest(t, app, "a")
+			expectStatus(t, resp, fiber.StatusTooManyRequests)
+			expectHeader(t, resp, "RateLimit-Remaining", "0")
+			retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			if err != nil || retryAfter < 1 || retryAfter > 60 {
+				t.Fatalf("expected Retry-After between 1 and 60 seconds, got %q", resp.Header.Get("Retry-After"))
+			}
+			expectHeader(t, resp, "X-RateLimit-RetryAfter", resp.Header.Get("Retry-After"))
+			if reset, err := strconv.Atoi(resp.Header.Get("RateLimit-Reset")); err != nil || reset < 1 || reset > 60 {
+				t.Fatalf("expected RateLimit-Reset between 1 and 60 seconds, got %q", resp.Header.Get("RateLimit-Reset"))
+			}
+
+			// Other clients have their own limit
+			expectStatus(t, doRequest(t, app, "b"), fiber.StatusOK)
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	app := newTestApp(RateLimiterConfig{
+		Max:       2,
+		Window:    time.Minute,
+		Algorithm: AlgorithmSlidingWindow,
+		Redis:     rdb,
+	})
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusOK)
+	mr.SetTime(testStart.Add(30 * time.Second))
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusOK)
+
+	// The first request leaves the window after 60 seconds, 30 seconds from now
+	resp := doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusTooManyRequests)
+	expectHeader(t, resp, "Retry-After", "30")
+	expectHeader(t, resp, "RateLimit-Reset", "60")
+
+	mr.SetTime(testStart.Add(61 * time.Second))
+	resp = doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusOK)
+	expectHeader(t, resp, "RateLimit-Remaining", "0")
+
+	// The second request is still in the window
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusTooManyRequests)
+}
+
+func TestTokenBucket(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	app := newTestApp(RateLimiterConfig{
+		Max:       3,
+		Window:    time.Minute,
+		Algorithm: AlgorithmTokenBucket,
+		Redis:     rdb,
+	})
+	for i := 0; i < 3; i++ {
+		expectStatus(t, doRequest(t, app, "a"), fiber.StatusOK)
+	}
+
+	// A token is refilled every 20 seconds
+	resp := doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusTooManyRequests)
+	expectHeader(t, resp, "Retry-After", "20")
+	expectHeader(t, resp, "RateLimit-Reset", "60")
+
+	mr.SetTime(testStart.Add(20 * time.Second))
+	resp = doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusOK)
+	expectHeader(t, resp, "RateLimit-Remaining", "0")
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusTooManyRequests)
+
+	// The bucket never holds more than Max tokens
+	mr.SetTime(testStart.Add(10 * time.Minute))
+	for i := 0; i < 3; i++ {
+		expectStatus(t, doRequest(t, app, "a"), fiber.StatusOK)
+	}
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusTooManyRequests)
+}
+
+func TestFixedWindow(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	app := newTestApp(RateLimiterConfig{
+		Max:       2,
+		Window:    time.Minute,
+		Algorithm: AlgorithmFixedWindow,
+		Redis:     rdb,
+	})
+
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusOK)
+	mr.FastForward(40 * time.Second)
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusOK)
+
+	// The window started with the first request
+	resp := doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusTooManyRequests)
+	expectHeader(t, resp, "Retry-After", "20")
+	expectHeader(t, resp, "RateLimit-Reset", "20")
+
+	mr.FastForward(20 * time.Second)
+	resp = doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusOK)
+	expectHeader(t, resp, "RateLimit-Remaining", "1")
+	expectHeader(t, resp, "RateLimit-Reset", "60")
+}
+
+func TestRateLimiterAPIKeyQuota(t *testing.T) {
+	_, rdb := newTestRedis(t)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(apiKeyLocal, &models.APIKey{ID: 1, Quotas: map[string]int{"search": 1}})
+		return c.Next()
+	})
+	app.Get("/", NewRateLimiter(RateLimiterConfig{
+		Max:    5,
+		Name:   "search",
+		Window: time.Minute,
+		Redis:  rdb,
+	}), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	resp := doRequest(t, app, "a")
+	expectStatus(t, resp, fiber.StatusOK)
+	expectHeader(t, resp, "RateLimit-Limit", "1")
+	expectStatus(t, doRequest(t, app, "a"), fiber.StatusTooManyRequests)
+}
+
+func TestRateLimiterRedisDown(t *testing.T) {
+	newDownApp := func(t *testing.T, policy string) *fiber.App {
+		mr, rdb := newTestRedis(t)
+		mr.Close()
+
+		return newTestApp(RateLimiterConfig{
+			Max:           2,
+			Window:        time.Minute,
+			Redis:         rdb,
+			Timeout:       100 * time.Millisecond,
+			FailurePolicy: policy,
+			Breaker:       NewCircuitBreaker("test", 1, 30*time.Second),
+		})
+	}
+
+	t.Run(FailOpen, func(t *testing.T) {
+		app := newDownApp(t, FailOpen)
+		for i := 0; i < 5; i++ {
+			resp := doRequest(t, app, "a")
+			expectStatus(t, resp, fiber.StatusOK)
+			expectHeader(t, resp, "RateLimit-Limit", "")
+		}
+	})
+
+	t.Run(FailClosed, func(t *testing.T) {
+		app := newDownApp(t, FailClosed)
+		for i := 0; i < 2; i++ {
+			resp := doRequest(t, app, "a")
+			expectStatus(t, resp, fiber.StatusServiceUnavailable)
+			expectHeader(t, resp, "Retry-After", "30")
+		}
+	})
+
+	t.Run(FailLocal, func(t *testing.T) {
+		app := newDownApp(t, FailLocal)
+		for i := 1; i <= 2; i++ {
+			resp := doRequest(t, app, "a")
+			expectStatus(t, resp, fiber.StatusOK)
+			expectHeader(t, resp, "RateLimit-Remaining", strconv.Itoa(2-i))
+		}
+
+		resp := doRequest(t, app, "a")
+		expectStatus(t, resp, fiber.StatusTooManyRequests)
+		expectHeader(t, resp, "Retry-After", "30")
+		expectStatus(t, doRequest(t, app, "b"), fiber.StatusOK)
+	})
+}
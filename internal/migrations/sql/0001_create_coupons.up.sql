@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    code VARCHAR(255) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    discount_value DECIMAL(10,2) NOT NULL,
+    discount_type VARCHAR(50) NOT NULL,
+    merchant_name VARCHAR(255) NOT NULL,
+    merchant_url TEXT NOT NULL,
+    
+    start_date TIMESTAMP,
+    end_date TIMESTAMP,
+    terms_conditions TEXT,
+    minimum_purchase_amount DECIMAL(10,2),
+    maximum_discount_amount DECIMAL(10,2),
+    
+    up_votes TIMESTAMP[] DEFAULT ARRAY[]::TIMESTAMP[],
+    down_votes TIMESTAMP[] DEFAULT ARRAY[]::TIMESTAMP[],
+    
+    categories TEXT[] DEFAULT ARRAY[]::TEXT[],
+    tags TEXT[] DEFAULT ARRAY[]::TEXT[],
+    regions TEXT[] DEFAULT ARRAY[]::TEXT[],
+    store_type VARCHAR(50),
+    
+    materialized_score DECIMAL(10,4),
+    last_score_update TIMESTAMP,
+    
+    CONSTRAINT valid_discount_type CHECK (
+        discount_type IN ('PERCENTAGE_OFF', 'FIXED_AMOUNT', 'BOGO', 'FREE_SHIPPING')
+    ),
+    CONSTRAINT valid_store_type CHECK (
+        store_type IN ('online', 'in_store', 'both')
+    )
+);
+
+-- Create indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_coupons_merchant ON coupons(merchant_name);
+CREATE INDEX IF NOT EXISTS idx_coupons_created_at ON coupons(created_at);
+CREATE INDEX IF NOT EXISTS idx_coupons_code ON coupons(code);
+CREATE INDEX IF NOT EXISTS idx_coupons_score ON coupons(materialized_score);
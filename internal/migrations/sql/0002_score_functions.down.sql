@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_score_trigger ON coupons;
+DROP FUNCTION IF EXISTS update_coupon_score();
+DROP FUNCTION IF EXISTS update_materialized_scores_batch(INT);
+DROP FUNCTION IF EXISTS calculate_coupon_score(DECIMAL, VARCHAR, DECIMAL, TIMESTAMP, TIMESTAMP[], TIMESTAMP[]);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_coupons_created_at_id;
+DROP INDEX IF EXISTS idx_coupons_score_id;
+DROP INDEX IF EXISTS idx_coupons_categories;
+DROP INDEX IF EXISTS idx_coupons_tags;
+DROP INDEX IF EXISTS idx_coupons_regions;
+DROP INDEX IF EXISTS idx_coupons_start_date;
+DROP INDEX IF EXISTS idx_coupons_end_date;
@@ -0,0 +1,8 @@
+-- Indexes for search filters, validity windows and keyset pagination
+CREATE INDEX IF NOT EXISTS idx_coupons_created_at_id ON coupons(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_coupons_score_id ON coupons((COALESCE(materialized_score, 0)), id);
+CREATE INDEX IF NOT EXISTS idx_coupons_categories ON coupons USING GIN (categories);
+CREATE INDEX IF NOT EXISTS idx_coupons_tags ON coupons USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_coupons_regions ON coupons USING GIN (regions);
+CREATE INDEX IF NOT EXISTS idx_coupons_start_date ON coupons(start_date);
+CREATE INDEX IF NOT EXISTS idx_coupons_end_date ON coupons(end_date);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_coupons_search_vector;
+ALTER TABLE coupons DROP COLUMN IF EXISTS search_vector;
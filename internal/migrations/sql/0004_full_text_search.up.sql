@@ -0,0 +1,8 @@
+-- Full-text search over title, description, merchant name and code
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english'::regconfig, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple'::regconfig, coalesce(code, '')), 'A') ||
+    setweight(to_tsvector('english'::regconfig, coalesce(merchant_name, '')), 'B') ||
+    setweight(to_tsvector('english'::regconfig, coalesce(description, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_coupons_search_vector ON coupons USING GIN (search_vector);
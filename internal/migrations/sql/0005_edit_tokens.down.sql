@@ -0,0 +1 @@
+ALTER TABLE coupons DROP COLUMN IF EXISTS edit_token_hash;
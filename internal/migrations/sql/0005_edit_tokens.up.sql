@@ -0,0 +1,2 @@
+-- SHA-256 of the secret token needed to edit or delete a coupon
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS edit_token_hash VARCHAR(64);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_coupons_duplicate;
+ALTER TABLE coupons DROP COLUMN IF EXISTS merchant_domain;
+DROP FUNCTION IF EXISTS normalize_merchant_domain(TEXT);
@@ -0,0 +1,11 @@
+-- Duplicate detection on the normalized merchant domain (no scheme, www., port or path) and code
+CREATE OR REPLACE FUNCTION normalize_merchant_domain(p_url TEXT) RETURNS TEXT AS $$
+    SELECT lower(regexp_replace(
+        substring(regexp_replace(btrim(p_url), '^[a-zA-Z][a-zA-Z0-9+.-]*://', '') from '^[^/?#:]*'),
+        '^www\.', ''
+    ))
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS merchant_domain TEXT
+    GENERATED ALWAYS AS (normalize_merchant_domain(merchant_url)) STORED;
+CREATE INDEX IF NOT EXISTS idx_coupons_duplicate ON coupons(merchant_domain, upper(btrim(code)));
@@ -0,0 +1,147 @@
+-- Move votes back into TIMESTAMP[] arrays on the coupon
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS up_votes TIMESTAMP[] DEFAULT ARRAY[]::TIMESTAMP[];
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS down_votes TIMESTAMP[] DEFAULT ARRAY[]::TIMESTAMP[];
+
+UPDATE coupons c
+SET up_votes = COALESCE((
+        SELECT ARRAY_AGG(v.created_at ORDER BY v.created_at)
+        FROM coupon_votes v
+        WHERE v.coupon_id = c.id AND v.direction = 'up'
+    ), ARRAY[]::TIMESTAMP[]),
+    down_votes = COALESCE((
+        SELECT ARRAY_AGG(v.created_at ORDER BY v.created_at)
+        FROM coupon_votes v
+        WHERE v.coupon_id = c.id AND v.direction = 'down'
+    ), ARRAY[]::TIMESTAMP[]);
+
+DROP TABLE IF EXISTS coupon_votes;
+DROP FUNCTION IF EXISTS update_voted_coupon_scores();
+DROP TRIGGER IF EXISTS update_score_trigger ON coupons;
+DROP FUNCTION IF EXISTS calculate_coupon_score(BIGINT, DECIMAL, VARCHAR, DECIMAL, TIMESTAMP);
+DROP FUNCTION IF EXISTS vote_weight(INTERVAL);
+
+-- Restore the array based score functions
+CREATE OR REPLACE FUNCTION calculate_coupon_score(
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP,
+    p_up_votes TIMESTAMP[],
+    p_down_votes TIMESTAMP[]
+) RETURNS DECIMAL AS $$
+DECLARE
+    vote_score DECIMAL;
+    discount_score DECIMAL;
+    freshness_score DECIMAL;
+BEGIN
+    -- Calculate vote score
+    SELECT COALESCE(
+        SUM(
+            CASE 
+                WHEN age < INTERVAL '1 day' THEN 1.0
+                WHEN age < INTERVAL '1 week' THEN 0.8
+                WHEN age < INTERVAL '1 month' THEN 0.6
+                WHEN age < INTERVAL '6 months' THEN 0.4
+                ELSE 0.2
+            END
+        ), 0) INTO vote_score
+    FROM (
+        SELECT CURRENT_TIMESTAMP - unnest(p_up_votes) as age
+    ) up;
+    
+    SELECT vote_score - COALESCE(
+        SUM(
+            CASE 
+                WHEN age < INTERVAL '1 day' THEN 1.0
+                WHEN age < INTERVAL '1 week' THEN 0.8
+                WHEN age < INTERVAL '1 month' THEN 0.6
+                WHEN age < INTERVAL '6 months' THEN 0.4
+                ELSE 0.2
+            END
+        ), 0) INTO vote_score
+    FROM (
+        SELECT CURRENT_TIMESTAMP - unnest(p_down_votes) as age
+    ) down;
+
+    -- Calculate discount score
+    discount_score := CASE 
+        WHEN p_discount_type = 'PERCENTAGE_OFF' THEN 
+            LEAST(p_discount_value / 100.0, 1.0)
+        WHEN p_discount_type = 'FIXED_AMOUNT' THEN 
+            CASE 
+                WHEN p_maximum_discount_amount > 0 THEN 
+                    LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
+                ELSE 
+                    LEAST(p_discount_value / 1000.0, 1.0)
+            END
+        WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN 
+            0.5
+    END;
+
+    -- Calculate freshness score
+    freshness_score := CASE 
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 day' THEN 1.0
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 week' THEN 0.8
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 month' THEN 0.6
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '3 months' THEN 0.4
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '6 months' THEN 0.2
+        ELSE 0.1
+    END;
+
+    -- Return weighted score
+    RETURN (vote_score * 0.4) + (discount_score * 0.4) + (freshness_score * 0.2);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create batch update function
+CREATE OR REPLACE FUNCTION update_materialized_scores_batch(batch_size INT) 
+RETURNS void AS $$
+BEGIN
+    WITH coupons_to_update AS (
+        SELECT id, discount_value, discount_type, maximum_discount_amount, 
+               created_at, up_votes, down_votes
+        FROM coupons 
+        WHERE last_score_update IS NULL
+        OR last_score_update < CURRENT_TIMESTAMP - INTERVAL '1 hour'
+        ORDER BY last_score_update NULLS FIRST 
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            ct.discount_value,
+            ct.discount_type,
+            ct.maximum_discount_amount,
+            ct.created_at,
+            ct.up_votes,
+            ct.down_votes
+        ),
+        last_score_update = CURRENT_TIMESTAMP
+    FROM coupons_to_update ct
+    WHERE c.id = ct.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create trigger function
+CREATE OR REPLACE FUNCTION update_coupon_score() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.materialized_score := calculate_coupon_score(
+        NEW.discount_value,
+        NEW.discount_type,
+        NEW.maximum_discount_amount,
+        NEW.created_at,
+        NEW.up_votes,
+        NEW.down_votes
+    );
+    NEW.last_score_update := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create trigger
+DROP TRIGGER IF EXISTS update_score_trigger ON coupons;
+CREATE TRIGGER update_score_trigger
+    BEFORE INSERT OR UPDATE OF discount_value, discount_type, maximum_discount_amount, up_votes, down_votes
+    ON coupons
+    FOR EACH ROW
+    EXECUTE FUNCTION update_coupon_score();
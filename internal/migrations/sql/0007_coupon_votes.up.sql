@@ -0,0 +1,170 @@
+-- Votes are stored one row per vote instead of TIMESTAMP[] arrays on the coupon
+CREATE TABLE IF NOT EXISTS coupon_votes (
+    id BIGSERIAL PRIMARY KEY,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    direction VARCHAR(4) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    voter_hash VARCHAR(64),
+
+    CONSTRAINT valid_direction CHECK (
+        direction IN ('up', 'down')
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_votes_coupon ON coupon_votes(coupon_id, created_at);
+
+-- Move existing votes out of the arrays
+INSERT INTO coupon_votes (coupon_id, direction, created_at)
+SELECT id, 'up', unnest(up_votes) FROM coupons;
+
+INSERT INTO coupon_votes (coupon_id, direction, created_at)
+SELECT id, 'down', unnest(down_votes) FROM coupons;
+
+-- Remove everything that depends on the arrays
+DROP TRIGGER IF EXISTS update_score_trigger ON coupons;
+DROP FUNCTION IF EXISTS calculate_coupon_score(DECIMAL, VARCHAR, DECIMAL, TIMESTAMP, TIMESTAMP[], TIMESTAMP[]);
+ALTER TABLE coupons DROP COLUMN IF EXISTS up_votes;
+ALTER TABLE coupons DROP COLUMN IF EXISTS down_votes;
+
+-- Weight of a vote depending on its age
+CREATE OR REPLACE FUNCTION vote_weight(p_age INTERVAL) RETURNS DECIMAL AS $$
+    SELECT CASE
+        WHEN p_age < INTERVAL '1 day' THEN 1.0
+        WHEN p_age < INTERVAL '1 week' THEN 0.8
+        WHEN p_age < INTERVAL '1 month' THEN 0.6
+        WHEN p_age < INTERVAL '6 months' THEN 0.4
+        ELSE 0.2
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION calculate_coupon_score(
+    p_coupon_id BIGINT,
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP
+) RETURNS DECIMAL AS $$
+DECLARE
+    vote_score DECIMAL;
+    discount_score DECIMAL;
+    freshness_score DECIMAL;
+BEGIN
+    -- Calculate vote score
+    SELECT COALESCE(
+        SUM(
+            CASE WHEN direction = 'up' THEN 1 ELSE -1 END * vote_weight(CURRENT_TIMESTAMP - created_at)
+        ), 0) INTO vote_score
+    FROM coupon_votes
+    WHERE coupon_id = p_coupon_id;
+
+    -- Calculate discount score
+    discount_score := CASE 
+        WHEN p_discount_type = 'PERCENTAGE_OFF' THEN 
+            LEAST(p_discount_value / 100.0, 1.0)
+        WHEN p_discount_type = 'FIXED_AMOUNT' THEN 
+            CASE 
+                WHEN p_maximum_discount_amount > 0 THEN 
+                    LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
+                ELSE 
+                    LEAST(p_discount_value / 1000.0, 1.0)
+            END
+        WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN 
+            0.5
+    END;
+
+    -- Calculate freshness score
+    freshness_score := CASE 
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 day' THEN 1.0
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 week' THEN 0.8
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 month' THEN 0.6
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '3 months' THEN 0.4
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '6 months' THEN 0.2
+        ELSE 0.1
+    END;
+
+    -- Return weighted score
+    RETURN (vote_score * 0.4) + (discount_score * 0.4) + (freshness_score * 0.2);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_materialized_scores_batch(batch_size INT) 
+RETURNS void AS $$
+BEGIN
+    WITH coupons_to_update AS (
+        SELECT id, discount_value, discount_type, maximum_discount_amount, created_at
+        FROM coupons 
+        WHERE last_score_update IS NULL
+        OR last_score_update < CURRENT_TIMESTAMP - INTERVAL '1 hour'
+        ORDER BY last_score_update NULLS FIRST 
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            ct.id,
+            ct.discount_value,
+            ct.discount_type,
+            ct.maximum_discount_amount,
+            ct.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP
+    FROM coupons_to_update ct
+    WHERE c.id = ct.id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_coupon_score() RETURNS TRIGGER AS $$
+BEGIN
+    -- NEW.id is already assigned from its sequence default in BEFORE INSERT triggers
+    NEW.materialized_score := calculate_coupon_score(
+        NEW.id,
+        NEW.discount_value,
+        NEW.discount_type,
+        NEW.maximum_discount_amount,
+        NEW.created_at
+    );
+    NEW.last_score_update := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_score_trigger
+    BEFORE INSERT OR UPDATE OF discount_value, discount_type, maximum_discount_amount
+    ON coupons
+    FOR EACH ROW
+    EXECUTE FUNCTION update_coupon_score();
+
+-- Recompute the score of every coupon that received votes, once per statement
+CREATE OR REPLACE FUNCTION update_voted_coupon_scores() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            c.id,
+            c.discount_value,
+            c.discount_type,
+            c.maximum_discount_amount,
+            c.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP
+    WHERE c.id IN (SELECT DISTINCT coupon_id FROM changed_votes);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS coupon_votes_insert_trigger ON coupon_votes;
+CREATE TRIGGER coupon_votes_insert_trigger
+    AFTER INSERT ON coupon_votes
+    REFERENCING NEW TABLE AS changed_votes
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION update_voted_coupon_scores();
+
+-- Scores of migrated coupons are recomputed from the new table
+UPDATE coupons c
+SET materialized_score = calculate_coupon_score(
+        c.id,
+        c.discount_value,
+        c.discount_type,
+        c.maximum_discount_amount,
+        c.created_at
+    ),
+    last_score_update = CURRENT_TIMESTAMP;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS coupon_votes_delete_trigger ON coupon_votes;
+DROP TRIGGER IF EXISTS coupon_votes_update_trigger ON coupon_votes;
+DROP INDEX IF EXISTS idx_coupon_votes_voter;
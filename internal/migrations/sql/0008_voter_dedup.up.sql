@@ -0,0 +1,17 @@
+-- One effective vote per voter and coupon, anonymous legacy votes (NULL voter_hash) are never equal
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupon_votes_voter ON coupon_votes(coupon_id, voter_hash);
+
+-- Changed and withdrawn votes affect the score as well
+DROP TRIGGER IF EXISTS coupon_votes_update_trigger ON coupon_votes;
+CREATE TRIGGER coupon_votes_update_trigger
+    AFTER UPDATE ON coupon_votes
+    REFERENCING NEW TABLE AS changed_votes
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION update_voted_coupon_scores();
+
+DROP TRIGGER IF EXISTS coupon_votes_delete_trigger ON coupon_votes;
+CREATE TRIGGER coupon_votes_delete_trigger
+    AFTER DELETE ON coupon_votes
+    REFERENCING OLD TABLE AS changed_votes
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION update_voted_coupon_scores();
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS coupons_updated_at_trigger ON coupons;
+DROP FUNCTION IF EXISTS set_coupon_updated_at();
+DROP INDEX IF EXISTS idx_coupons_updated_at;
+ALTER TABLE coupons DROP COLUMN IF EXISTS updated_at;
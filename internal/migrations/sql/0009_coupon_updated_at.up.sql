@@ -0,0 +1,35 @@
+-- Time of the last change to a coupon's data, used for incremental exports.
+-- Score and vote updates do not count as changes.
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE coupons SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE coupons ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE coupons ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_coupons_updated_at ON coupons(updated_at, id);
+
+CREATE OR REPLACE FUNCTION set_coupon_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS coupons_updated_at_trigger ON coupons;
+CREATE TRIGGER coupons_updated_at_trigger
+    BEFORE UPDATE OF
+        code, title, description, discount_value, discount_type,
+        merchant_name, merchant_url, start_date, end_date,
+        terms_conditions, minimum_purchase_amount, maximum_discount_amount,
+        categories, tags, regions, store_type
+    ON coupons
+    FOR EACH ROW
+    WHEN ((OLD.code, OLD.title, OLD.description, OLD.discount_value, OLD.discount_type,
+           OLD.merchant_name, OLD.merchant_url, OLD.start_date, OLD.end_date,
+           OLD.terms_conditions, OLD.minimum_purchase_amount, OLD.maximum_discount_amount,
+           OLD.categories, OLD.tags, OLD.regions, OLD.store_type)
+          IS DISTINCT FROM
+          (NEW.code, NEW.title, NEW.description, NEW.discount_value, NEW.discount_type,
+           NEW.merchant_name, NEW.merchant_url, NEW.start_date, NEW.end_date,
+           NEW.terms_conditions, NEW.minimum_purchase_amount, NEW.maximum_discount_amount,
+           NEW.categories, NEW.tags, NEW.regions, NEW.store_type))
+    EXECUTE FUNCTION set_coupon_updated_at();
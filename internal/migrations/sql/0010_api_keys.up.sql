@@ -0,0 +1,19 @@
+-- API keys identify clients for scoped endpoints and per-key rate limits, only the SHA-256 of a key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT ARRAY[]::TEXT[],
+    -- Requests per window of the named rate limits, overriding their defaults
+    quotas JSONB NOT NULL DEFAULT '{}'::JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+
+    CONSTRAINT valid_scopes CHECK (
+        scopes <@ ARRAY['read', 'submit', 'vote', 'bulk', 'admin']::TEXT[]
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_hash ON api_keys(key_hash);
@@ -0,0 +1,9 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS trusted;
+
+DROP TABLE IF EXISTS moderation_log;
+
+DROP INDEX IF EXISTS idx_coupons_moderation_queue;
+ALTER TABLE coupons DROP CONSTRAINT IF EXISTS valid_moderation_status;
+ALTER TABLE coupons DROP COLUMN IF EXISTS moderated_at;
+ALTER TABLE coupons DROP COLUMN IF EXISTS moderation_reason;
+ALTER TABLE coupons DROP COLUMN IF EXISTS moderation_status;
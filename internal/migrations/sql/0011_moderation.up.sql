@@ -0,0 +1,28 @@
+-- Submitted coupons wait for review, coupons that existed before moderation stay visible
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved';
+ALTER TABLE coupons ALTER COLUMN moderation_status SET DEFAULT 'pending';
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMP;
+
+ALTER TABLE coupons DROP CONSTRAINT IF EXISTS valid_moderation_status;
+ALTER TABLE coupons ADD CONSTRAINT valid_moderation_status CHECK (
+    moderation_status IN ('pending', 'approved', 'rejected', 'hidden')
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupons_moderation_queue ON coupons(moderation_status, created_at, id);
+
+-- Every moderation decision, api_key_id is NULL for automatic ones
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id BIGSERIAL PRIMARY KEY,
+    coupon_id BIGINT NOT NULL,
+    previous_status VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    api_key_id BIGINT REFERENCES api_keys(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_log_coupon ON moderation_log(coupon_id, id);
+
+-- Coupons submitted with a trusted key are approved without review
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS trusted BOOLEAN NOT NULL DEFAULT false;
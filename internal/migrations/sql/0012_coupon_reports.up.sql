@@ -0,0 +1,15 @@
+-- User reports about broken or abusive coupons, one per reporter and coupon
+CREATE TABLE IF NOT EXISTS coupon_reports (
+    id BIGSERIAL PRIMARY KEY,
+    coupon_id BIGINT NOT NULL REFERENCES coupons(id) ON DELETE CASCADE,
+    reason VARCHAR(20) NOT NULL,
+    comment TEXT,
+    reporter_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT valid_report_reason CHECK (
+        reason IN ('expired', 'wrong_merchant', 'spam', 'offensive')
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coupon_reports_reporter ON coupon_reports(coupon_id, reporter_hash);
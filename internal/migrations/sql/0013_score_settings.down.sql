@@ -0,0 +1,54 @@
+-- Back to the hard-coded formula
+CREATE OR REPLACE FUNCTION calculate_coupon_score(
+    p_coupon_id BIGINT,
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP
+) RETURNS DECIMAL AS $$
+DECLARE
+    vote_score DECIMAL;
+    discount_score DECIMAL;
+    freshness_score DECIMAL;
+BEGIN
+    -- Calculate vote score
+    SELECT COALESCE(
+        SUM(
+            CASE WHEN direction = 'up' THEN 1 ELSE -1 END * vote_weight(CURRENT_TIMESTAMP - created_at)
+        ), 0) INTO vote_score
+    FROM coupon_votes
+    WHERE coupon_id = p_coupon_id;
+
+    -- Calculate discount score
+    discount_score := CASE 
+        WHEN p_discount_type = 'PERCENTAGE_OFF' THEN 
+            LEAST(p_discount_value / 100.0, 1.0)
+        WHEN p_discount_type = 'FIXED_AMOUNT' THEN 
+            CASE 
+                WHEN p_maximum_discount_amount > 0 THEN 
+                    LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
+                ELSE 
+                    LEAST(p_discount_value / 1000.0, 1.0)
+            END
+        WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN 
+            0.5
+    END;
+
+    -- Calculate freshness score
+    freshness_score := CASE 
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 day' THEN 1.0
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 week' THEN 0.8
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 month' THEN 0.6
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '3 months' THEN 0.4
+        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '6 months' THEN 0.2
+        ELSE 0.1
+    END;
+
+    -- Return weighted score
+    RETURN (vote_score * 0.4) + (discount_score * 0.4) + (freshness_score * 0.2);
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS coupon_score_components(BIGINT, DECIMAL, VARCHAR, DECIMAL, TIMESTAMP);
+DROP FUNCTION IF EXISTS decay_weight(INTERVAL, JSONB, DECIMAL);
+DROP TABLE IF EXISTS score_settings;
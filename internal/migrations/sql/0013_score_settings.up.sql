@@ -0,0 +1,100 @@
+-- Versioned ranking parameters, the latest version is used to compute scores
+CREATE TABLE IF NOT EXISTS score_settings (
+    version SERIAL PRIMARY KEY,
+    vote_weight DECIMAL(10,4) NOT NULL,
+    discount_weight DECIMAL(10,4) NOT NULL,
+    freshness_weight DECIMAL(10,4) NOT NULL,
+    -- Decay curves are [{"max_age_hours": 24, "weight": 1.0}, ...], ages beyond the last step get the floor weight
+    vote_decay JSONB NOT NULL,
+    vote_decay_floor DECIMAL(10,4) NOT NULL,
+    freshness_decay JSONB NOT NULL,
+    freshness_decay_floor DECIMAL(10,4) NOT NULL,
+    -- Fixed amount that counts as a full discount when the coupon has no maximum discount amount
+    fixed_amount_reference DECIMAL(10,2) NOT NULL,
+    -- Discount score of BOGO and FREE_SHIPPING coupons
+    flat_discount_score DECIMAL(10,4) NOT NULL,
+    comment TEXT,
+    created_by BIGINT REFERENCES api_keys(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The formula used until now
+INSERT INTO score_settings (
+    vote_weight, discount_weight, freshness_weight,
+    vote_decay, vote_decay_floor, freshness_decay, freshness_decay_floor,
+    fixed_amount_reference, flat_discount_score, comment
+) VALUES (
+    0.4, 0.4, 0.2,
+    '[{"max_age_hours": 24, "weight": 1.0}, {"max_age_hours": 168, "weight": 0.8},
+      {"max_age_hours": 720, "weight": 0.6}, {"max_age_hours": 4320, "weight": 0.4}]', 0.2,
+    '[{"max_age_hours": 24, "weight": 1.0}, {"max_age_hours": 168, "weight": 0.8},
+      {"max_age_hours": 720, "weight": 0.6}, {"max_age_hours": 2160, "weight": 0.4},
+      {"max_age_hours": 4320, "weight": 0.2}]', 0.1,
+    1000, 0.5, 'Initial formula'
+);
+
+-- Weight of the first decay step whose maximum age is above p_age
+CREATE OR REPLACE FUNCTION decay_weight(p_age INTERVAL, p_steps JSONB, p_floor DECIMAL) RETURNS DECIMAL AS $$
+    SELECT COALESCE((
+        SELECT (step->>'weight')::DECIMAL
+        FROM jsonb_array_elements(p_steps) AS step
+        WHERE p_age < (step->>'max_age_hours')::DECIMAL * INTERVAL '1 hour'
+        ORDER BY (step->>'max_age_hours')::DECIMAL
+        LIMIT 1
+    ), p_floor)
+$$ LANGUAGE sql IMMUTABLE;
+
+-- The parts of a coupon's score under the latest settings, the score is the sum of each part times its weight
+CREATE OR REPLACE FUNCTION coupon_score_components(
+    p_coupon_id BIGINT,
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP
+) RETURNS TABLE (
+    settings_version INT,
+    vote_score DECIMAL,
+    vote_weight DECIMAL,
+    discount_score DECIMAL,
+    discount_weight DECIMAL,
+    freshness_score DECIMAL,
+    freshness_weight DECIMAL
+) AS $$
+    SELECT
+        s.version,
+        (
+            SELECT COALESCE(SUM(
+                CASE WHEN v.direction = 'up' THEN 1 ELSE -1 END
+                * decay_weight(CURRENT_TIMESTAMP - v.created_at, s.vote_decay, s.vote_decay_floor)
+            ), 0)
+            FROM coupon_votes v
+            WHERE v.coupon_id = p_coupon_id
+        ),
+        s.vote_weight,
+        CASE
+            WHEN p_discount_type = 'PERCENTAGE_OFF' THEN
+                LEAST(p_discount_value / 100.0, 1.0)
+            WHEN p_discount_type = 'FIXED_AMOUNT' AND p_maximum_discount_amount > 0 THEN
+                LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
+            WHEN p_discount_type = 'FIXED_AMOUNT' THEN
+                LEAST(p_discount_value / s.fixed_amount_reference, 1.0)
+            WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN
+                s.flat_discount_score
+        END,
+        s.discount_weight,
+        decay_weight(CURRENT_TIMESTAMP - p_created_at, s.freshness_decay, s.freshness_decay_floor),
+        s.freshness_weight
+    FROM (SELECT * FROM score_settings ORDER BY version DESC LIMIT 1) s
+$$ LANGUAGE sql STABLE;
+
+-- Same signature as before, so the triggers and the batch update keep working
+CREATE OR REPLACE FUNCTION calculate_coupon_score(
+    p_coupon_id BIGINT,
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP
+) RETURNS DECIMAL AS $$
+    SELECT vote_score * vote_weight + discount_score * discount_weight + freshness_score * freshness_weight
+    FROM coupon_score_components(p_coupon_id, p_discount_value, p_discount_type, p_maximum_discount_amount, p_created_at)
+$$ LANGUAGE sql STABLE;
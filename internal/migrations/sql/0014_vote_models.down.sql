@@ -0,0 +1,49 @@
+CREATE OR REPLACE FUNCTION coupon_score_components(
+    p_coupon_id BIGINT,
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP
+) RETURNS TABLE (
+    settings_version INT,
+    vote_score DECIMAL,
+    vote_weight DECIMAL,
+    discount_score DECIMAL,
+    discount_weight DECIMAL,
+    freshness_score DECIMAL,
+    freshness_weight DECIMAL
+) AS $$
+    SELECT
+        s.version,
+        (
+            SELECT COALESCE(SUM(
+                CASE WHEN v.direction = 'up' THEN 1 ELSE -1 END
+                * decay_weight(CURRENT_TIMESTAMP - v.created_at, s.vote_decay, s.vote_decay_floor)
+            ), 0)
+            FROM coupon_votes v
+            WHERE v.coupon_id = p_coupon_id
+        ),
+        s.vote_weight,
+        CASE
+            WHEN p_discount_type = 'PERCENTAGE_OFF' THEN
+                LEAST(p_discount_value / 100.0, 1.0)
+            WHEN p_discount_type = 'FIXED_AMOUNT' AND p_maximum_discount_amount > 0 THEN
+                LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
+            WHEN p_discount_type = 'FIXED_AMOUNT' THEN
+                LEAST(p_discount_value / s.fixed_amount_reference, 1.0)
+            WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN
+                s.flat_discount_score
+        END,
+        s.discount_weight,
+        decay_weight(CURRENT_TIMESTAMP - p_created_at, s.freshness_decay, s.freshness_decay_floor),
+        s.freshness_weight
+    FROM (SELECT * FROM score_settings ORDER BY version DESC LIMIT 1) s
+$$ LANGUAGE sql STABLE;
+
+DROP FUNCTION IF EXISTS vote_model_score(VARCHAR, DECIMAL, DECIMAL, DECIMAL, DECIMAL, DECIMAL);
+
+ALTER TABLE score_settings DROP CONSTRAINT IF EXISTS valid_vote_model;
+ALTER TABLE score_settings DROP COLUMN IF EXISTS prior_weight;
+ALTER TABLE score_settings DROP COLUMN IF EXISTS prior_mean;
+ALTER TABLE score_settings DROP COLUMN IF EXISTS wilson_z;
+ALTER TABLE score_settings DROP COLUMN IF EXISTS vote_model;
@@ -0,0 +1,87 @@
+-- How votes are turned into the vote part of the score:
+-- net      weighted up votes minus weighted down votes, unbounded
+-- wilson   lower bound of the Wilson score interval of the weighted share of up votes, between 0 and 1
+-- bayesian weighted share of up votes with prior_weight imaginary votes of share prior_mean, between 0 and 1
+ALTER TABLE score_settings ADD COLUMN IF NOT EXISTS vote_model VARCHAR(10) NOT NULL DEFAULT 'net';
+ALTER TABLE score_settings ADD COLUMN IF NOT EXISTS wilson_z DECIMAL(10,4) NOT NULL DEFAULT 1.96;
+ALTER TABLE score_settings ADD COLUMN IF NOT EXISTS prior_mean DECIMAL(10,4) NOT NULL DEFAULT 0.5;
+ALTER TABLE score_settings ADD COLUMN IF NOT EXISTS prior_weight DECIMAL(10,4) NOT NULL DEFAULT 5;
+
+ALTER TABLE score_settings ADD CONSTRAINT valid_vote_model CHECK (
+    vote_model IN ('net', 'wilson', 'bayesian')
+);
+
+-- Vote part of the score from the decayed sums of up and down votes
+CREATE OR REPLACE FUNCTION vote_model_score(
+    p_model VARCHAR,
+    p_up DECIMAL,
+    p_down DECIMAL,
+    p_wilson_z DECIMAL,
+    p_prior_mean DECIMAL,
+    p_prior_weight DECIMAL
+) RETURNS DECIMAL AS $$
+DECLARE
+    n DECIMAL := p_up + p_down;
+    p DECIMAL;
+    z2 DECIMAL := p_wilson_z * p_wilson_z;
+BEGIN
+    IF p_model = 'wilson' THEN
+        IF n <= 0 THEN
+            RETURN 0;
+        END IF;
+        p := p_up / n;
+        RETURN (p + z2 / (2 * n) - p_wilson_z * sqrt((p * (1 - p) + z2 / (4 * n)) / n)) / (1 + z2 / n);
+    ELSIF p_model = 'bayesian' THEN
+        IF n + p_prior_weight <= 0 THEN
+            RETURN p_prior_mean;
+        END IF;
+        RETURN (p_up + p_prior_mean * p_prior_weight) / (n + p_prior_weight);
+    END IF;
+
+    RETURN p_up - p_down;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION coupon_score_components(
+    p_coupon_id BIGINT,
+    p_discount_value DECIMAL,
+    p_discount_type VARCHAR,
+    p_maximum_discount_amount DECIMAL,
+    p_created_at TIMESTAMP
+) RETURNS TABLE (
+    settings_version INT,
+    vote_score DECIMAL,
+    vote_weight DECIMAL,
+    discount_score DECIMAL,
+    discount_weight DECIMAL,
+    freshness_score DECIMAL,
+    freshness_weight DECIMAL
+) AS $$
+    SELECT
+        s.version,
+        vote_model_score(s.vote_model, v.up, v.down, s.wilson_z, s.prior_mean, s.prior_weight),
+        s.vote_weight,
+        CASE
+            WHEN p_discount_type = 'PERCENTAGE_OFF' THEN
+                LEAST(p_discount_value / 100.0, 1.0)
+            WHEN p_discount_type = 'FIXED_AMOUNT' AND p_maximum_discount_amount > 0 THEN
+                LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
+            WHEN p_discount_type = 'FIXED_AMOUNT' THEN
+                LEAST(p_discount_value / s.fixed_amount_reference, 1.0)
+            WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN
+                s.flat_discount_score
+        END,
+        s.discount_weight,
+        decay_weight(CURRENT_TIMESTAMP - p_created_at, s.freshness_decay, s.freshness_decay_floor),
+        s.freshness_weight
+    FROM (SELECT * FROM score_settings ORDER BY version DESC LIMIT 1) s
+    CROSS JOIN LATERAL (
+        SELECT
+            COALESCE(SUM(decay_weight(CURRENT_TIMESTAMP - cv.created_at, s.vote_decay, s.vote_decay_floor))
+                FILTER (WHERE cv.direction = 'up'), 0) AS up,
+            COALESCE(SUM(decay_weight(CURRENT_TIMESTAMP - cv.created_at, s.vote_decay, s.vote_decay_floor))
+                FILTER (WHERE cv.direction = 'down'), 0) AS down
+        FROM coupon_votes cv
+        WHERE cv.coupon_id = p_coupon_id
+    ) v
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,63 @@
+DROP FUNCTION IF EXISTS update_due_scores_batch(INT);
+
+CREATE OR REPLACE FUNCTION update_materialized_scores_batch(batch_size INT) 
+RETURNS void AS $$
+BEGIN
+    WITH coupons_to_update AS (
+        SELECT id, discount_value, discount_type, maximum_discount_amount, created_at
+        FROM coupons 
+        WHERE last_score_update IS NULL
+        OR last_score_update < CURRENT_TIMESTAMP - INTERVAL '1 hour'
+        ORDER BY last_score_update NULLS FIRST 
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            ct.id,
+            ct.discount_value,
+            ct.discount_type,
+            ct.maximum_discount_amount,
+            ct.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP
+    FROM coupons_to_update ct
+    WHERE c.id = ct.id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_coupon_score() RETURNS TRIGGER AS $$
+BEGIN
+    -- NEW.id is already assigned from its sequence default in BEFORE INSERT triggers
+    NEW.materialized_score := calculate_coupon_score(
+        NEW.id,
+        NEW.discount_value,
+        NEW.discount_type,
+        NEW.maximum_discount_amount,
+        NEW.created_at
+    );
+    NEW.last_score_update := CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_voted_coupon_scores() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            c.id,
+            c.discount_value,
+            c.discount_type,
+            c.maximum_discount_amount,
+            c.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP
+    WHERE c.id IN (SELECT DISTINCT coupon_id FROM changed_votes);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS next_score_change(BIGINT, TIMESTAMP);
+
+DROP INDEX IF EXISTS idx_coupons_next_score_update;
+ALTER TABLE coupons DROP COLUMN IF EXISTS next_score_update;
@@ -0,0 +1,92 @@
+-- Scores only change over time when a vote or the coupon itself crosses a decay step boundary.
+-- next_score_update is the next such boundary, NULL if the score only changes with new votes or edits.
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS next_score_update TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_coupons_next_score_update ON coupons(next_score_update)
+    WHERE next_score_update IS NOT NULL;
+
+-- Earliest future time at which the freshness or a vote of the coupon reaches the next decay step
+CREATE OR REPLACE FUNCTION next_score_change(p_coupon_id BIGINT, p_created_at TIMESTAMP) RETURNS TIMESTAMP AS $$
+    WITH s AS (
+        SELECT * FROM score_settings ORDER BY version DESC LIMIT 1
+    )
+    SELECT MIN(boundary) FROM (
+        SELECT p_created_at + (step->>'max_age_hours')::DECIMAL * INTERVAL '1 hour' AS boundary
+        FROM s, jsonb_array_elements(s.freshness_decay) AS step
+        UNION ALL
+        SELECT cv.created_at + (step->>'max_age_hours')::DECIMAL * INTERVAL '1 hour'
+        FROM s, jsonb_array_elements(s.vote_decay) AS step, coupon_votes cv
+        WHERE cv.coupon_id = p_coupon_id
+    ) boundaries
+    WHERE boundary > CURRENT_TIMESTAMP
+$$ LANGUAGE sql STABLE;
+
+-- Replaces update_materialized_scores_batch, only recomputes coupons whose score is due to change.
+-- Returns the number of recomputed coupons, less than batch_size once none are due.
+DROP FUNCTION IF EXISTS update_materialized_scores_batch(INT);
+
+CREATE OR REPLACE FUNCTION update_due_scores_batch(batch_size INT) RETURNS INT AS $$
+DECLARE
+    updated INT;
+BEGIN
+    WITH coupons_to_update AS (
+        SELECT id
+        FROM coupons
+        WHERE next_score_update <= CURRENT_TIMESTAMP
+        ORDER BY next_score_update
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            c.id,
+            c.discount_value,
+            c.discount_type,
+            c.maximum_discount_amount,
+            c.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP,
+        next_score_update = next_score_change(c.id, c.created_at)
+    FROM coupons_to_update ct
+    WHERE c.id = ct.id;
+
+    GET DIAGNOSTICS updated = ROW_COUNT;
+    RETURN updated;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_coupon_score() RETURNS TRIGGER AS $$
+BEGIN
+    -- NEW.id is already assigned from its sequence default in BEFORE INSERT triggers
+    NEW.materialized_score := calculate_coupon_score(
+        NEW.id,
+        NEW.discount_value,
+        NEW.discount_type,
+        NEW.maximum_discount_amount,
+        NEW.created_at
+    );
+    NEW.last_score_update := CURRENT_TIMESTAMP;
+    NEW.next_score_update := next_score_change(NEW.id, NEW.created_at);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION update_voted_coupon_scores() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            c.id,
+            c.discount_value,
+            c.discount_type,
+            c.maximum_discount_amount,
+            c.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP,
+        next_score_update = next_score_change(c.id, c.created_at)
+    WHERE c.id IN (SELECT DISTINCT coupon_id FROM changed_votes);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Every coupon is recomputed once by the next run, which schedules its following update
+UPDATE coupons SET next_score_update = CURRENT_TIMESTAMP;
@@ -0,0 +1,25 @@
+-- History of background job runs
+CREATE TABLE IF NOT EXISTS job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(10) NOT NULL,
+    -- Schedule slot of scheduled runs, each slot runs on one replica only
+    scheduled_for TIMESTAMP,
+    instance VARCHAR(255) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'running',
+    error TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms BIGINT,
+
+    CONSTRAINT valid_job_trigger CHECK (
+        trigger IN ('schedule', 'manual')
+    ),
+    CONSTRAINT valid_job_status CHECK (
+        status IN ('running', 'succeeded', 'failed')
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_runs_slot ON job_runs(job_name, scheduled_for)
+    WHERE scheduled_for IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_job_runs_job ON job_runs(job_name, started_at DESC);
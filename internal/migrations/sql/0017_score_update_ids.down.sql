@@ -0,0 +1,31 @@
+DROP FUNCTION IF EXISTS update_due_scores_batch(INT);
+
+CREATE OR REPLACE FUNCTION update_due_scores_batch(batch_size INT) RETURNS INT AS $$
+DECLARE
+    updated INT;
+BEGIN
+    WITH coupons_to_update AS (
+        SELECT id
+        FROM coupons
+        WHERE next_score_update <= CURRENT_TIMESTAMP
+        ORDER BY next_score_update
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            c.id,
+            c.discount_value,
+            c.discount_type,
+            c.maximum_discount_amount,
+            c.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP,
+        next_score_update = next_score_change(c.id, c.created_at)
+    FROM coupons_to_update ct
+    WHERE c.id = ct.id;
+
+    GET DIAGNOSTICS updated = ROW_COUNT;
+    RETURN updated;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,31 @@
+-- update_due_scores_batch returns the recomputed coupons, so only their cache entries have to be invalidated.
+-- Returns fewer than batch_size rows once no more coupons are due.
+DROP FUNCTION IF EXISTS update_due_scores_batch(INT);
+
+CREATE OR REPLACE FUNCTION update_due_scores_batch(batch_size INT)
+RETURNS TABLE (id BIGINT, merchant_name TEXT) AS $$
+BEGIN
+    RETURN QUERY
+    WITH coupons_to_update AS (
+        SELECT c.id
+        FROM coupons c
+        WHERE c.next_score_update <= CURRENT_TIMESTAMP
+        ORDER BY c.next_score_update
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    UPDATE coupons c
+    SET materialized_score = calculate_coupon_score(
+            c.id,
+            c.discount_value,
+            c.discount_type,
+            c.maximum_discount_amount,
+            c.created_at
+        ),
+        last_score_update = CURRENT_TIMESTAMP,
+        next_score_update = next_score_change(c.id, c.created_at)
+    FROM coupons_to_update ct
+    WHERE c.id = ct.id
+    RETURNING c.id, c.merchant_name::TEXT;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,24 @@
+DROP TRIGGER IF EXISTS coupons_removal_status_trigger ON coupons;
+DROP TRIGGER IF EXISTS coupons_removal_delete_trigger ON coupons;
+DROP FUNCTION IF EXISTS record_coupon_removal();
+DROP TABLE IF EXISTS coupon_removals;
+
+DROP TRIGGER IF EXISTS coupons_updated_at_trigger ON coupons;
+CREATE TRIGGER coupons_updated_at_trigger
+    BEFORE UPDATE OF
+        code, title, description, discount_value, discount_type,
+        merchant_name, merchant_url, start_date, end_date,
+        terms_conditions, minimum_purchase_amount, maximum_discount_amount,
+        categories, tags, regions, store_type
+    ON coupons
+    FOR EACH ROW
+    WHEN ((OLD.code, OLD.title, OLD.description, OLD.discount_value, OLD.discount_type,
+           OLD.merchant_name, OLD.merchant_url, OLD.start_date, OLD.end_date,
+           OLD.terms_conditions, OLD.minimum_purchase_amount, OLD.maximum_discount_amount,
+           OLD.categories, OLD.tags, OLD.regions, OLD.store_type)
+          IS DISTINCT FROM
+          (NEW.code, NEW.title, NEW.description, NEW.discount_value, NEW.discount_type,
+           NEW.merchant_name, NEW.merchant_url, NEW.start_date, NEW.end_date,
+           NEW.terms_conditions, NEW.minimum_purchase_amount, NEW.maximum_discount_amount,
+           NEW.categories, NEW.tags, NEW.regions, NEW.store_type))
+    EXECUTE FUNCTION set_coupon_updated_at();
@@ -0,0 +1,68 @@
+-- Changes of the moderation status count as changes of a coupon, so incremental exports
+-- see coupons that got approved again.
+DROP TRIGGER IF EXISTS coupons_updated_at_trigger ON coupons;
+CREATE TRIGGER coupons_updated_at_trigger
+    BEFORE UPDATE OF
+        code, title, description, discount_value, discount_type,
+        merchant_name, merchant_url, start_date, end_date,
+        terms_conditions, minimum_purchase_amount, maximum_discount_amount,
+        categories, tags, regions, store_type, moderation_status
+    ON coupons
+    FOR EACH ROW
+    WHEN ((OLD.code, OLD.title, OLD.description, OLD.discount_value, OLD.discount_type,
+           OLD.merchant_name, OLD.merchant_url, OLD.start_date, OLD.end_date,
+           OLD.terms_conditions, OLD.minimum_purchase_amount, OLD.maximum_discount_amount,
+           OLD.categories, OLD.tags, OLD.regions, OLD.store_type, OLD.moderation_status)
+          IS DISTINCT FROM
+          (NEW.code, NEW.title, NEW.description, NEW.discount_value, NEW.discount_type,
+           NEW.merchant_name, NEW.merchant_url, NEW.start_date, NEW.end_date,
+           NEW.terms_conditions, NEW.minimum_purchase_amount, NEW.maximum_discount_amount,
+           NEW.categories, NEW.tags, NEW.regions, NEW.store_type, NEW.moderation_status))
+    EXECUTE FUNCTION set_coupon_updated_at();
+
+-- Published coupons that were deleted or un-published, so incremental exports can pass on their removal.
+-- A coupon that is approved again loses its row.
+CREATE TABLE IF NOT EXISTS coupon_removals (
+    coupon_id BIGINT PRIMARY KEY,
+    removed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- 'deleted' or the new moderation status
+    reason VARCHAR(20) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_removals_removed_at ON coupon_removals(removed_at, coupon_id);
+
+CREATE OR REPLACE FUNCTION record_coupon_removal() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO coupon_removals (coupon_id, reason)
+        VALUES (OLD.id, 'deleted')
+        ON CONFLICT (coupon_id) DO UPDATE
+        SET removed_at = CURRENT_TIMESTAMP, reason = EXCLUDED.reason;
+        RETURN OLD;
+    END IF;
+
+    IF NEW.moderation_status = 'approved' THEN
+        DELETE FROM coupon_removals WHERE coupon_id = NEW.id;
+    ELSE
+        INSERT INTO coupon_removals (coupon_id, reason)
+        VALUES (NEW.id, NEW.moderation_status)
+        ON CONFLICT (coupon_id) DO UPDATE
+        SET removed_at = CURRENT_TIMESTAMP, reason = EXCLUDED.reason;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS coupons_removal_delete_trigger ON coupons;
+CREATE TRIGGER coupons_removal_delete_trigger
+    AFTER DELETE ON coupons
+    FOR EACH ROW
+    WHEN (OLD.moderation_status = 'approved')
+    EXECUTE FUNCTION record_coupon_removal();
+
+DROP TRIGGER IF EXISTS coupons_removal_status_trigger ON coupons;
+CREATE TRIGGER coupons_removal_status_trigger
+    AFTER UPDATE OF moderation_status ON coupons
+    FOR EACH ROW
+    WHEN ((OLD.moderation_status = 'approved') <> (NEW.moderation_status = 'approved'))
+    EXECUTE FUNCTION record_coupon_removal();
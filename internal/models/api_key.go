@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// API key scopes, ScopeAdmin includes all other scopes
+const (
+	ScopeRead   = "read"
+	ScopeSubmit = "submit"
+	ScopeVote   = "vote"
+	ScopeBulk   = "bulk"
+	ScopeAdmin  = "admin"
+)
+
+// Scopes lists every valid API key scope
+var Scopes = []string{ScopeRead, ScopeSubmit, ScopeVote, ScopeBulk, ScopeAdmin}
+
+type APIKey struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"` // first characters of the key, to recognize it in listings
+	Scopes []string `json:"scopes"`
+
+	// Requests per window of the named rate limits, overriding their defaults
+	Quotas map[string]int `json:"quotas,omitempty"`
+
+	// Coupons submitted with a trusted key are approved without review
+	Trusted bool `json:"trusted"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // updated at most once per cache period
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package models
+
+// Outcomes of a single row of a bulk import
+const (
+	BulkRowCreated   = "created"
+	BulkRowValid     = "valid" // dry run only, the row would have been created
+	BulkRowInvalid   = "invalid"
+	BulkRowDuplicate = "duplicate"
+)
+
+type BulkImportRowResult struct {
+	Row    int    `json:"row" example:"1"` // 1-based position of the row in the input, not counting a CSV header
+	Status string `json:"status" example:"created" enums:"created,valid,invalid,duplicate"`
+
+	ID             int64  `json:"id,omitempty" example:"42"`
+	ExistingID     int64  `json:"existing_id,omitempty" example:"17"`     // duplicate of a coupon already in the database
+	DuplicateOfRow int    `json:"duplicate_of_row,omitempty" example:"3"` // duplicate of an earlier row of the same import
+	Error          string `json:"error,omitempty" example:"Coupon code is required"`
+}
+
+type BulkImportResponse struct {
+	DryRun     bool                  `json:"dry_run" example:"false"`
+	Total      int                   `json:"total" example:"3"`
+	Created    int                   `json:"created" example:"1"`
+	Valid      int                   `json:"valid" example:"0"`
+	Invalid    int                   `json:"invalid" example:"1"`
+	Duplicates int                   `json:"duplicates" example:"1"`
+	Results    []BulkImportRowResult `json:"results"`
+}
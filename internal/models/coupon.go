@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type DiscountType string
 
@@ -15,6 +18,7 @@ type Coupon struct {
 	// Required Information
 	ID            int64        `json:"id"`
 	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"` // last change of the coupon's data, not of its votes or score
 	Code          string       `json:"code"`
 	Title         string       `json:"title"`
 	Description   string       `json:"description"`
@@ -29,10 +33,10 @@ type Coupon struct {
 	TermsConditions       string     `json:"terms_conditions,omitempty"`
 	MinimumPurchaseAmount float64    `json:"minimum_purchase_amount,omitempty"`
 	MaximumDiscountAmount float64    `json:"maximum_discount_amount,omitempty"`
+	IsActive              bool       `json:"is_active"` // derived from start_date and end_date
 
 	// Voting Information
-	UpVotes   TimestampArray `json:"up_votes"`
-	DownVotes TimestampArray `json:"down_votes"`
+	Votes VoteStats `json:"votes"`
 
 	// Metadata
 	Categories []string `json:"categories,omitempty"`
@@ -43,18 +47,46 @@ type Coupon struct {
 	// Score calculated by db
 	MaterializedScore float64    `json:"score"`
 	LastScoreUpdate   *time.Time `json:"-"` // not exposed to API
+
+	// Hash of the token required to edit or delete the coupon
+	EditTokenHash string `json:"-"`
+
+	// Moderation state, not part of search results, which only contain approved coupons
+	ModerationStatus string `json:"moderation_status,omitempty"`
+	ModerationReason string `json:"moderation_reason,omitempty"`
+}
+
+// IsActiveAt reports whether the coupon's validity window contains t
+func (c *Coupon) IsActiveAt(t time.Time) bool {
+	if c.StartDate != nil && c.StartDate.After(t) {
+		return false
+	}
+	if c.EndDate != nil && !c.EndDate.After(t) {
+		return false
+	}
+	return true
+}
+
+// CouponRemoval records that a published coupon was deleted or is no longer approved
+type CouponRemoval struct {
+	ID        int64     `json:"id"`
+	RemovedAt time.Time `json:"removed_at"`
+	Reason    string    `json:"reason"` // "deleted" or the new moderation status
 }
 
 type CouponsSearchResponse struct {
 	Data   []Coupon `json:"data" example:[{"id":1,"title":"Discount","description":"Get 10% off","score":5,"created_at":"2021-01-01T00:00:00Z"}]`
-	Total  int      `json:"total" example:"100"`
+	Total  *int64   `json:"total,omitempty" example:"100"` // omitted if include_total=false
 	Limit  int      `json:"limit" example:"10"`
 	Offset int      `json:"offset" example:"0"`
+
+	// Cursor of the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoibmV3ZXN0IiwiayI6IjIwMjQtMDEtMDEgMDA6MDA6MDAiLCJpIjo0Mn0"`
 }
 
 type CouponsSearchParams struct {
 	SearchString string `json:"search_string" example:"discount"`
-	SortBy       string `json:"sort_by" example:"newest" enums:"newest,oldest,high_score,low_score"`
+	SortBy       string `json:"sort_by" example:"newest" enums:"newest,oldest,high_score,low_score,relevance"`
 	Limit        int    `json:"limit" example:"10" minimum:"1"`
 	Offset       int    `json:"offset" example:"0" minimum:"0"`
 }
@@ -87,4 +119,57 @@ type CouponCreateResponse struct {
 	ID                int64     `json:"id"`
 	CreatedAt         time.Time `json:"created_at"`
 	MaterializedScore float64   `json:"score"`
+
+	// Secret token required to edit or delete the coupon, it is only returned once.
+	// Empty if the submission was merged into an existing coupon.
+	EditToken string `json:"edit_token,omitempty" example:"3f9a1c0d5e7b2a4c6e8f0a1b3c5d7e9f3f9a1c0d5e7b2a4c6e8f0a1b3c5d7e9f"`
+
+	// True if the submission was merged into an existing coupon (on_duplicate=merge)
+	Merged bool `json:"merged" example:"false"`
+
+	// Moderation state, new coupons are pending until reviewed unless submitted with a trusted API key
+	Status string `json:"status" example:"pending" enums:"pending,approved,rejected,hidden"`
+}
+
+type DuplicateCouponResponse struct {
+	Message    string `json:"message" example:"Coupon already exists"`
+	ExistingID int64  `json:"existing_id" example:"42"`
+}
+
+// NullableTime is a time of an update request that tells an omitted value from an explicit null
+type NullableTime struct {
+	Set   bool       // the field was present, possibly as null
+	Value *time.Time // nil if the field was null
+}
+
+func (t *NullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &t.Value)
+}
+
+// CouponUpdateRequest contains the fields to change, omitted fields keep their current value
+type CouponUpdateRequest struct {
+	Code          *string       `json:"code,omitempty"`
+	Title         *string       `json:"title,omitempty"`
+	Description   *string       `json:"description,omitempty"`
+	DiscountValue *float64      `json:"discount_value,omitempty"`
+	DiscountType  *DiscountType `json:"discount_type,omitempty"`
+	MerchantName  *string       `json:"merchant_name,omitempty"`
+	MerchantURL   *string       `json:"merchant_url,omitempty"`
+
+	// Send null to remove the date
+	StartDate             NullableTime `json:"start_date,omitempty" swaggertype:"string" format:"date-time" extensions:"x-nullable"`
+	EndDate               NullableTime `json:"end_date,omitempty" swaggertype:"string" format:"date-time" extensions:"x-nullable"`
+	TermsConditions       *string      `json:"terms_conditions,omitempty"`
+	MinimumPurchaseAmount *float64     `json:"minimum_purchase_amount,omitempty"`
+	MaximumDiscountAmount *float64     `json:"maximum_discount_amount,omitempty"`
+
+	Categories *[]string `json:"categories,omitempty"`
+	Tags       *[]string `json:"tags,omitempty"`
+	Regions    *[]string `json:"regions,omitempty"`
+	StoreType  *string   `json:"store_type,omitempty"`
 }
@@ -1,6 +1,13 @@
 package models
 
 type HealthCheckResponse struct {
-	Status  string `json:"status" example:"ok"`
-	Version string `json:"version" example:"1.0"`
+	Status      string                `json:"status" example:"ok"`
+	Version     string                `json:"version" example:"1.0"`
+	RateLimiter RateLimiterHealthInfo `json:"rate_limiter"`
+}
+
+// RateLimiterHealthInfo tells whether rate limits are counted in Redis or by the failure policy
+type RateLimiterHealthInfo struct {
+	Mode    string `json:"mode" example:"redis" enums:"redis,local,fail_open,fail_closed"`
+	Breaker string `json:"breaker" example:"closed" enums:"closed,open,half_open"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// How a job run was started
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// States of a job run, runs of a crashed replica are marked failed by the next run of the job
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+type JobRun struct {
+	ID           int64      `json:"id" example:"1"`
+	JobName      string     `json:"job_name" example:"score_update"`
+	Trigger      string     `json:"trigger" example:"schedule" enums:"schedule,manual"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	Instance     string     `json:"instance" example:"api-1-7"`
+	Status       string     `json:"status" example:"succeeded" enums:"running,succeeded,failed"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	DurationMs   *int64     `json:"duration_ms,omitempty" example:"1520"`
+}
+
+// JobStatus describes a scheduled job and its latest runs, newest first
+type JobStatus struct {
+	Name           string     `json:"name" example:"score_update"`
+	Schedule       string     `json:"schedule" example:"*/10 * * * *"`
+	TimeoutSeconds int64      `json:"timeout_seconds" example:"300"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	Runs           []JobRun   `json:"runs"`
+}
+
+// WorkerStatus describes a long running worker of this replica, which is restarted when it fails
+type WorkerStatus struct {
+	Name      string `json:"name" example:"vote_processor"`
+	Running   bool   `json:"running" example:"true"`
+	Restarts  int    `json:"restarts" example:"0"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type JobsResponse struct {
+	Jobs    []JobStatus    `json:"jobs"`
+	Workers []WorkerStatus `json:"workers"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Moderation states of a coupon, only approved coupons are public
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+	ModerationHidden   = "hidden"
+)
+
+// ModerationLogEntry records a change of a coupon's moderation state
+type ModerationLogEntry struct {
+	ID             int64     `json:"id" example:"1"`
+	CouponID       int64     `json:"coupon_id" example:"42"`
+	PreviousStatus string    `json:"previous_status" example:"pending"`
+	Status         string    `json:"status" example:"rejected"`
+	Reason         string    `json:"reason,omitempty" example:"Joke code"`
+	APIKeyID       *int64    `json:"api_key_id,omitempty" example:"3"` // moderator's key, empty for automatic changes
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ModerationQueueResponse struct {
+	Data   []Coupon `json:"data"`
+	Total  int64    `json:"total" example:"12"`
+	Limit  int      `json:"limit" example:"50"`
+	Offset int      `json:"offset" example:"0"`
+}
+
+type ModerationLogResponse struct {
+	Data   []ModerationLogEntry `json:"data"`
+	Limit  int                  `json:"limit" example:"50"`
+	Offset int                  `json:"offset" example:"0"`
+}
+
+type ModerationRejectRequest struct {
+	Reason string `json:"reason" example:"Joke code"`
+}
+
+type ModerationHideMerchantRequest struct {
+	MerchantDomain string `json:"merchant_domain" example:"example.com"` // a URL of the merchant works as well
+	Reason         string `json:"reason" example:"Merchant closed"`
+}
+
+type ModerationHideMerchantResponse struct {
+	Hidden int `json:"hidden" example:"17"` // number of coupons hidden
+}
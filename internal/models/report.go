@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Reasons for reporting a coupon
+const (
+	ReportExpired       = "expired"
+	ReportWrongMerchant = "wrong_merchant"
+	ReportSpam          = "spam"
+	ReportOffensive     = "offensive"
+)
+
+type Report struct {
+	CouponID     int64
+	Reason       string
+	Comment      string
+	ReporterHash string
+}
+
+type ReportRequest struct {
+	Reason  string `json:"reason" example:"expired" enums:"expired,wrong_merchant,spam,offensive"`
+	Comment string `json:"comment,omitempty" example:"Code was rejected at checkout"`
+}
+
+// CouponReportStats aggregates the reports of a coupon
+type CouponReportStats struct {
+	CouponID       int64     `json:"coupon_id" example:"42"`
+	Total          int64     `json:"total" example:"6"`
+	Expired        int64     `json:"expired" example:"4"`
+	WrongMerchant  int64     `json:"wrong_merchant" example:"0"`
+	Spam           int64     `json:"spam" example:"2"`
+	Offensive      int64     `json:"offensive" example:"0"`
+	LastReportedAt time.Time `json:"last_reported_at"`
+}
+
+type ReportStatsResponse struct {
+	Data   []CouponReportStats `json:"data"`
+	Limit  int                 `json:"limit" example:"50"`
+	Offset int                 `json:"offset" example:"0"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// Vote models, how votes are turned into the vote part of the score
+const (
+	VoteModelNet      = "net"      // weighted up votes minus weighted down votes
+	VoteModelWilson   = "wilson"   // lower bound of the Wilson score interval of the share of up votes
+	VoteModelBayesian = "bayesian" // share of up votes, starting from PriorWeight votes with a share of PriorMean
+)
+
+// Defaults of the vote model parameters
+const (
+	DefaultWilsonZ     = 1.96 // 95% confidence
+	DefaultPriorMean   = 0.5
+	DefaultPriorWeight = 5
+)
+
+// DecayStep gives everything younger than MaxAgeHours the weight Weight, unless an earlier step matched
+type DecayStep struct {
+	MaxAgeHours float64 `json:"max_age_hours" example:"24"`
+	Weight      float64 `json:"weight" example:"1"`
+}
+
+// ScoreSettings are the parameters of the ranking formula:
+// score = vote * VoteWeight + discount * DiscountWeight + freshness * FreshnessWeight
+type ScoreSettings struct {
+	Version             int64       `json:"version" example:"2"`
+	VoteModel           string      `json:"vote_model" example:"wilson" enums:"net,wilson,bayesian"`
+	WilsonZ             float64     `json:"wilson_z" example:"1.96"`
+	PriorMean           float64     `json:"prior_mean" example:"0.5"`
+	PriorWeight         float64     `json:"prior_weight" example:"5"`
+	VoteWeight          float64     `json:"vote_weight" example:"0.4"`
+	DiscountWeight      float64     `json:"discount_weight" example:"0.4"`
+	FreshnessWeight     float64     `json:"freshness_weight" example:"0.2"`
+	VoteDecay           []DecayStep `json:"vote_decay"`
+	VoteDecayFloor      float64     `json:"vote_decay_floor" example:"0.2"`
+	FreshnessDecay      []DecayStep `json:"freshness_decay"`
+	FreshnessDecayFloor float64     `json:"freshness_decay_floor" example:"0.1"`
+	// Fixed amount that counts as a full discount when the coupon has no maximum discount amount
+	FixedAmountReference float64 `json:"fixed_amount_reference" example:"1000"`
+	// Discount score of BOGO and FREE_SHIPPING coupons
+	FlatDiscountScore float64   `json:"flat_discount_score" example:"0.5"`
+	Comment           string    `json:"comment,omitempty" example:"Favour fresh coupons"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ScoreSettingsRequest creates a new version of the score settings, which replaces the current one
+type ScoreSettingsRequest struct {
+	VoteModel            string      `json:"vote_model,omitempty" example:"wilson" enums:"net,wilson,bayesian"`
+	WilsonZ              *float64    `json:"wilson_z,omitempty" example:"1.96"`
+	PriorMean            *float64    `json:"prior_mean,omitempty" example:"0.5"`
+	PriorWeight          *float64    `json:"prior_weight,omitempty" example:"5"`
+	VoteWeight           float64     `json:"vote_weight" example:"0.4"`
+	DiscountWeight       float64     `json:"discount_weight" example:"0.4"`
+	FreshnessWeight      float64     `json:"freshness_weight" example:"0.2"`
+	VoteDecay            []DecayStep `json:"vote_decay"`
+	VoteDecayFloor       float64     `json:"vote_decay_floor" example:"0.2"`
+	FreshnessDecay       []DecayStep `json:"freshness_decay"`
+	FreshnessDecayFloor  float64     `json:"freshness_decay_floor" example:"0.1"`
+	FixedAmountReference float64     `json:"fixed_amount_reference" example:"1000"`
+	FlatDiscountScore    float64     `json:"flat_discount_score" example:"0.5"`
+	Comment              string      `json:"comment,omitempty" example:"Favour fresh coupons"`
+}
+
+type ScoreSettingsListResponse struct {
+	Data   []ScoreSettings `json:"data"`
+	Limit  int             `json:"limit" example:"50"`
+	Offset int             `json:"offset" example:"0"`
+}
+
+// ScoreComponent is one part of a coupon's score, its contribution is Value * Weight
+type ScoreComponent struct {
+	Value        float64 `json:"value" example:"0.75"`
+	Weight       float64 `json:"weight" example:"0.4"`
+	Contribution float64 `json:"contribution" example:"0.3"`
+}
+
+// ScoreExplanation breaks a coupon's score down into its parts under the current score settings.
+// Score is the stored score used for sorting, which is recomputed periodically and can lag behind Total.
+type ScoreExplanation struct {
+	CouponID        int64          `json:"coupon_id" example:"42"`
+	Score           float64        `json:"score" example:"0.62"`
+	Total           float64        `json:"total" example:"0.64"`
+	SettingsVersion int64          `json:"settings_version" example:"1"`
+	VoteModel       string         `json:"vote_model" example:"wilson"`
+	Vote            ScoreComponent `json:"vote"`
+	Discount        ScoreComponent `json:"discount"`
+	Freshness       ScoreComponent `json:"freshness"`
+	LastScoreUpdate *time.Time     `json:"last_score_update,omitempty"`
+}
@@ -2,12 +2,29 @@ package models
 
 import "time"
 
+// Vote directions, VoteWithdraw removes the voter's previous vote
+const (
+	VoteUp       = "up"
+	VoteDown     = "down"
+	VoteWithdraw = "withdraw"
+)
+
 type Vote struct {
 	ID        int64
 	Timestamp time.Time
+	Direction string
+	VoterHash string // empty for legacy queue entries, which cannot be deduplicated
 }
 
 type VoteBody struct {
 	ID  int64  `json:"id"`
 	Dir string `json:"dir"`
 }
+
+// VoteStats aggregates the votes of a coupon, recent votes are the ones of the last 7 days
+type VoteStats struct {
+	Up         int64 `json:"up" example:"12"`
+	Down       int64 `json:"down" example:"3"`
+	RecentUp   int64 `json:"recent_up" example:"4"`
+	RecentDown int64 `json:"recent_down" example:"1"`
+}
@@ -0,0 +1,362 @@
+package queue
+
+import (
+	"context"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/models"
+	"discountdb-api/internal/repositories"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// VoteStream holds queued votes until a processor has applied them
+	VoteStream = "vote_stream"
+	// VoteDeadLetterStream holds entries that could not be parsed or kept failing, for manual inspection
+	VoteDeadLetterStream = "vote_dead_letter"
+
+	voteGroup = "vote_processors"
+
+	// legacyVoteQueue is the list used before votes moved to a stream, it is drained on startup
+	legacyVoteQueue = "vote_queue"
+
+	// maxStreamLen bounds the stream length, acknowledged entries are trimmed first
+	maxStreamLen = 1_000_000
+)
+
+// VoteMessage is a single queued vote
+type VoteMessage struct {
+	ID        int64     `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	VoteType  string    `json:"vote_type"`
+	VoterHash string    `json:"voter_hash,omitempty"`
+}
+
+// EnqueueVote adds a vote to the vote stream
+func EnqueueVote(ctx context.Context, rdb *redis.Client, vote VoteMessage) error {
+	data, err := json.Marshal(vote)
+	if err != nil {
+		return err
+	}
+
+	return rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: VoteStream,
+		MaxLen: maxStreamLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	}).Err()
+}
+
+// VoteProcessor applies queued votes in batches.
+//
+// Processors share a consumer group, so every replica of the API can run one and each vote is applied once.
+// Entries are only acknowledged after their batch was written to the database; entries of a crashed or
+// failing processor stay pending and are claimed again by any processor once they have been idle for ClaimIdle.
+// Entries that cannot be parsed, or that were delivered MaxDeliveries times, are moved to VoteDeadLetterStream.
+type VoteProcessor struct {
+	couponRepo *repositories.CouponRepository
+	rdb        *redis.Client
+	store      *cache.Store
+	consumer   string
+
+	BatchSize     int
+	Block         time.Duration // how long to wait for new entries before checking for stale ones
+	ClaimIdle     time.Duration // how long an entry has to be pending before another processor takes it over
+	MaxDeliveries int64         // deliveries after which an entry is dead-lettered
+	MaxRetries    int           // retries of a failing batch before leaving it to be claimed again
+	RetryBackoff  time.Duration // initial delay between retries, doubled after every attempt
+	MaxBackoff    time.Duration
+}
+
+func NewVoteProcessor(couponRepo *repositories.CouponRepository, rdb *redis.Client, store *cache.Store, batchSize int) *VoteProcessor {
+	hostname, _ := os.Hostname()
+
+	return &VoteProcessor{
+		couponRepo:    couponRepo,
+		rdb:           rdb,
+		store:         store,
+		consumer:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		BatchSize:     batchSize,
+		Block:         5 * time.Second,
+		ClaimIdle:     time.Minute,
+		MaxDeliveries: 5,
+		MaxRetries:    3,
+		RetryBackoff:  time.Second,
+		MaxBackoff:    time.Minute,
+	}
+}
+
+// Run processes votes until ctx is cancelled. Errors are logged and retried with backoff, they never stop the processor.
+func (p *VoteProcessor) Run(ctx context.Context) {
+	backoff := p.RetryBackoff
+
+	for ctx.Err() == nil {
+		err := p.setup(ctx)
+		if err == nil {
+			err = p.poll(ctx)
+		}
+
+		if err == nil || ctx.Err() != nil {
+			backoff = p.RetryBackoff
+			continue
+		}
+
+		log.Printf("Vote processor error: %v", err)
+		if !sleep(ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+}
+
+// setup creates the consumer group and moves votes left in the legacy list to the stream
+func (p *VoteProcessor) setup(ctx context.Context) error {
+	err := p.rdb.XGroupCreateMkStream(ctx, VoteStream, voteGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	for {
+		// LPOP is atomic, so concurrent replicas never move the same entry twice
+		data, err := p.rdb.LPop(ctx, legacyVoteQueue).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to drain legacy vote queue: %w", err)
+		}
+
+		err = p.rdb.XAdd(ctx, &redis.XAddArgs{
+			Stream: VoteStream,
+			Values: map[string]interface{}{"data": data},
+		}).Err()
+		if err != nil {
+			// Put it back so the vote is not lost
+			p.rdb.LPush(context.WithoutCancel(ctx), legacyVoteQueue, data)
+			return fmt.Errorf("failed to move legacy vote: %w", err)
+		}
+	}
+}
+
+// poll handles stale pending entries first and then waits for new ones, repeating until ctx is cancelled or an error occurs
+func (p *VoteProcessor) poll(ctx context.Context) error {
+	for ctx.Err() == nil {
+		if err := p.claimStale(ctx); err != nil {
+			return err
+		}
+
+		streams, err := p.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    voteGroup,
+			Consumer: p.consumer,
+			Streams:  []string{VoteStream, ">"},
+			Count:    int64(p.BatchSize),
+			Block:    p.Block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read votes: %w", err)
+		}
+
+		for _, stream := range streams {
+			p.process(ctx, stream.Messages)
+		}
+	}
+	return nil
+}
+
+// claimStale takes over entries that have been pending for too long, e.g. because their processor died
+func (p *VoteProcessor) claimStale(ctx context.Context) error {
+	pending, err := p.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: VoteStream,
+		Group:  voteGroup,
+		Idle:   p.ClaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(p.BatchSize),
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list pending votes: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(pending))
+	deliveries := make(map[string]int64, len(pending))
+	for i, entry := range pending {
+		ids[i] = entry.ID
+		deliveries[entry.ID] = entry.RetryCount
+	}
+
+	// XCLAIM only returns the entries that are still idle, so concurrent processors never claim the same entry
+	messages, err := p.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   VoteStream,
+		Group:    voteGroup,
+		Consumer: p.consumer,
+		MinIdle:  p.ClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim pending votes: %w", err)
+	}
+
+	if len(messages) > 0 {
+		log.Printf("Claimed %d stale votes", len(messages))
+	}
+
+	retry := make([]redis.XMessage, 0, len(messages))
+	for _, message := range messages {
+		if deliveries[message.ID] >= p.MaxDeliveries {
+			p.deadLetter(ctx, message, fmt.Sprintf("failed after %d deliveries", deliveries[message.ID]))
+			continue
+		}
+		retry = append(retry, message)
+	}
+
+	// Retry one by one, so a single entry the database keeps rejecting does not hold back the others
+	for _, message := range retry {
+		if ctx.Err() != nil {
+			// Shutting down, the remaining entries are claimed again later
+			return nil
+		}
+		p.process(ctx, []redis.XMessage{message})
+	}
+	return nil
+}
+
+// process applies a batch of entries and acknowledges them. Failing batches are left pending to be claimed again.
+func (p *VoteProcessor) process(ctx context.Context, messages []redis.XMessage) {
+	// Finish the current batch even if ctx is cancelled meanwhile, so a shutdown does not leave it half done
+	stopping := ctx.Done()
+	ctx = context.WithoutCancel(ctx)
+
+	votes := make([]models.Vote, 0, len(messages))
+	ids := make([]string, 0, len(messages))
+
+	for _, message := range messages {
+		vote, err := parseVote(message)
+		if err != nil {
+			p.deadLetter(ctx, message, err.Error())
+			continue
+		}
+		votes = append(votes, vote)
+		ids = append(ids, message.ID)
+	}
+	if len(votes) == 0 {
+		return
+	}
+
+	var coupons []repositories.RescoredCoupon
+	backoff := p.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		var err error
+		coupons, err = p.couponRepo.ApplyVotes(ctx, votes)
+		if err == nil {
+			break
+		}
+		if attempt == p.MaxRetries {
+			log.Printf("Failed to apply %d votes, leaving them pending: %v", len(votes), err)
+			return
+		}
+
+		log.Printf("Failed to apply %d votes, retrying in %s: %v", len(votes), backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-stopping:
+			// Do not hold up a shutdown with retries, another processor claims the votes later
+			log.Printf("Shutting down, leaving %d votes pending", len(votes))
+			return
+		}
+		backoff = min(backoff*2, p.MaxBackoff)
+	}
+
+	if err := p.rdb.XAck(ctx, VoteStream, voteGroup, ids...).Err(); err != nil {
+		// The votes will be applied again once claimed, which is harmless as applying a vote is idempotent
+		log.Printf("Failed to acknowledge %d votes: %v", len(ids), err)
+	}
+
+	// The scores of the voted coupons changed
+	merchantNames := make(map[int64]string, len(coupons))
+	for _, coupon := range coupons {
+		merchantNames[coupon.ID] = coupon.MerchantName
+	}
+	p.store.Invalidate(ctx, cache.ScoreTags(merchantNames)...)
+}
+
+// deadLetter moves an entry to the dead letter stream and acknowledges it
+func (p *VoteProcessor) deadLetter(ctx context.Context, message redis.XMessage, reason string) {
+	values := make(map[string]interface{}, len(message.Values)+2)
+	for k, v := range message.Values {
+		values[k] = v
+	}
+	values["original_id"] = message.ID
+	values["error"] = reason
+
+	if err := p.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: VoteDeadLetterStream,
+		MaxLen: maxStreamLen,
+		Approx: true,
+		Values: values,
+	}).Err(); err != nil {
+		// Leave the entry pending rather than losing it
+		log.Printf("Failed to dead-letter vote %s: %v", message.ID, err)
+		return
+	}
+
+	log.Printf("Moved vote %s to %s: %s", message.ID, VoteDeadLetterStream, reason)
+	if err := p.rdb.XAck(ctx, VoteStream, voteGroup, message.ID).Err(); err != nil {
+		log.Printf("Failed to acknowledge vote %s: %v", message.ID, err)
+	}
+}
+
+// parseVote validates a stream entry and converts it to a vote
+func parseVote(message redis.XMessage) (models.Vote, error) {
+	data, ok := message.Values["data"].(string)
+	if !ok {
+		return models.Vote{}, errors.New("missing data field")
+	}
+
+	var vote VoteMessage
+	if err := json.Unmarshal([]byte(data), &vote); err != nil {
+		return models.Vote{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if vote.ID < 1 {
+		return models.Vote{}, fmt.Errorf("invalid coupon id %d", vote.ID)
+	}
+	switch vote.VoteType {
+	case models.VoteUp, models.VoteDown, models.VoteWithdraw:
+	default:
+		return models.Vote{}, fmt.Errorf("invalid vote type %q", vote.VoteType)
+	}
+	if vote.Timestamp.IsZero() {
+		return models.Vote{}, errors.New("missing timestamp")
+	}
+
+	return models.Vote{
+		ID:        vote.ID,
+		Timestamp: vote.Timestamp,
+		Direction: vote.VoteType,
+		VoterHash: vote.VoterHash,
+	}, nil
+}
+
+// sleep waits for d and reports whether ctx is still active
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
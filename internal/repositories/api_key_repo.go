@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"discountdb-api/internal/models"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to recognize
+const apiKeyPrefix = "ddb_"
+
+// apiKeyPrefixLength is the number of leading characters stored to recognize a key in listings
+const apiKeyPrefixLength = 12
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// NewAPIKeySecret returns a random API key and the hash that is stored in the database
+func NewAPIKeySecret() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of key, only hashes are stored in the database
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+const apiKeyColumns = `id, name, key_prefix, scopes, quotas, trusted, created_at, last_used_at, revoked_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var quotas []byte
+	err := row.Scan(
+		&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &quotas, &key.Trusted,
+		&key.CreatedAt, &key.LastUsedAt, &key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(quotas, &key.Quotas); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Create stores a new API key for the given secret and fills in its id, prefix and creation time
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey, secret string) error {
+	quotas, err := json.Marshal(key.Quotas)
+	if err != nil {
+		return err
+	}
+	if key.Quotas == nil {
+		quotas = []byte("{}")
+	}
+
+	key.Prefix = secret[:min(apiKeyPrefixLength, len(secret))]
+	return r.db.QueryRowContext(ctx, `
+        INSERT INTO api_keys (name, key_hash, key_prefix, scopes, quotas, trusted)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at`,
+		key.Name, HashAPIKey(secret), key.Prefix, pq.Array(key.Scopes), string(quotas), key.Trusted,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetByHash returns the active API key with the given hash and records that it was used.
+// Returns ErrAPIKeyNotFound if no such key exists or it was revoked.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, `
+        UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+        WHERE key_hash = $1 AND revoked_at IS NULL
+        RETURNING `+apiKeyColumns,
+		keyHash,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// List returns all API keys including revoked ones, oldest first
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke disables an API key. Returns ErrAPIKeyNotFound if no active key has the given id.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAPIKeyNotFound)
+}
+
+// SetQuotas replaces the rate limit quotas of an API key. Returns ErrAPIKeyNotFound if no key has the given id.
+func (r *APIKeyRepository) SetQuotas(ctx context.Context, id int64, quotas map[string]int) error {
+	data, err := json.Marshal(quotas)
+	if err != nil {
+		return err
+	}
+	if quotas == nil {
+		data = []byte("{}")
+	}
+
+	result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET quotas = $2 WHERE id = $1`, id, string(data))
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAPIKeyNotFound)
+}
+
+// SetTrusted sets whether coupons submitted with an API key are approved without review.
+// Returns ErrAPIKeyNotFound if no key has the given id.
+func (r *APIKeyRepository) SetTrusted(ctx context.Context, id int64, trusted bool) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET trusted = $2 WHERE id = $1`, id, trusted)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAPIKeyNotFound)
+}
+
+// requireAffected returns notFound if result did not affect any row
+func requireAffected(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+	return nil
+}
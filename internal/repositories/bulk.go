@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/models"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+)
+
+// bulkInsertBatchSize is the number of rows per multi-row INSERT, keeping the parameters well below the limit of 65535
+const bulkInsertBatchSize = 500
+
+// Duplicate checks lock the merchant domain with pg_advisory_xact_lock(duplicateLockClass, bucket), where the bucket
+// is the domain's hash masked with duplicateLockMask. Domains share the 64 buckets, so a large import takes at most
+// 64 locks instead of one per domain, which would exhaust the shared lock table.
+const (
+	duplicateLockClass = "hashtext('coupon_duplicates')"
+	duplicateLockMask  = "63"
+)
+
+// BatchResult is the outcome of a single coupon passed to CreateBatch
+type BatchResult struct {
+	ID          int64 // id of the inserted coupon, 0 if it was skipped or in a dry run
+	ExistingID  int64 // id of the coupon in the database with the same code and merchant domain
+	DuplicateOf int   // index of an earlier coupon of the batch with the same code and merchant domain, or -1
+}
+
+// Duplicate reports whether the coupon was skipped as a duplicate
+func (r BatchResult) Duplicate() bool {
+	return r.ExistingID != 0 || r.DuplicateOf >= 0
+}
+
+// CreateBatch inserts the coupons in a single transaction. Coupons whose code (ignoring case) already exists for
+// their merchant domain, either in the database or earlier in the batch, are skipped. As in CreateUnique, rejected
+// and hidden coupons in the database do not count. In a dry run the duplicates
+// are detected but nothing is written. The results are in the same order as coupons, whose ids are filled in.
+func (r *CouponRepository) CreateBatch(ctx context.Context, coupons []models.Coupon, dryRun bool) (results []BatchResult, err error) {
+	if len(coupons) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err != nil || dryRun {
+			tx.Rollback()
+		}
+	}()
+
+	urls := make([]string, len(coupons))
+	codes := make([]string, len(coupons))
+	for i, coupon := range coupons {
+		urls[i] = coupon.MerchantURL
+		codes[i] = coupon.Code
+	}
+
+	// Take the same locks as CreateUnique, in a fixed order so concurrent imports cannot deadlock
+	if !dryRun {
+		_, err = tx.ExecContext(ctx, `
+            SELECT pg_advisory_xact_lock(`+duplicateLockClass+`, k) FROM (
+                SELECT DISTINCT hashtext(normalize_merchant_domain(u)) & `+duplicateLockMask+` AS k
+                FROM unnest($1::text[]) AS t(u)
+                ORDER BY k
+            ) AS keys`,
+			pq.Array(urls),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+        SELECT t.i, normalize_merchant_domain(t.url) || ':' || upper(btrim(t.code)), COALESCE(existing.id, 0)
+        FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS t(url, code, i)
+        LEFT JOIN LATERAL (
+            SELECT id FROM coupons c
+            WHERE c.merchant_domain = normalize_merchant_domain(t.url) AND upper(btrim(c.code)) = upper(btrim(t.code))
+                AND c.moderation_status IN ('pending', 'approved')
+            ORDER BY id
+            LIMIT 1
+        ) AS existing ON true
+        ORDER BY t.i`,
+		pq.Array(urls), pq.Array(codes),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]BatchResult, len(coupons))
+	keys := make([]string, len(coupons))
+	firstByKey := make(map[string]int, len(coupons))
+	var pending []int
+	for rows.Next() {
+		var i int
+		var key string
+		var existingID int64
+		if err = rows.Scan(&i, &key, &existingID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i-- // ordinality starts at 1
+		keys[i] = key
+
+		results[i] = BatchResult{ExistingID: existingID, DuplicateOf: -1}
+		if first, ok := firstByKey[key]; ok && existingID == 0 {
+			results[i].DuplicateOf = first
+		} else if !ok {
+			firstByKey[key] = i
+		}
+		if !results[i].Duplicate() {
+			pending = append(pending, i)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	for start := 0; start < len(pending); start += bulkInsertBatchSize {
+		batch := pending[start:min(start+bulkInsertBatchSize, len(pending))]
+		if err = insertCoupons(ctx, tx, coupons, keys, batch); err != nil {
+			return nil, err
+		}
+		for _, i := range batch {
+			results[i].ID = coupons[i].ID
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// insertCoupons inserts the coupons at the given indexes with a single multi-row INSERT and fills in their ids.
+// keys holds the duplicate key of every coupon, which must be unique among the inserted ones.
+func insertCoupons(ctx context.Context, tx *sql.Tx, coupons []models.Coupon, keys []string, indexes []int) error {
+	const columns = 18
+
+	var query strings.Builder
+	query.WriteString(`
+        INSERT INTO coupons (
+            code, title, description, discount_value, discount_type,
+            merchant_name, merchant_url, start_date, end_date,
+            terms_conditions, minimum_purchase_amount, maximum_discount_amount,
+            categories, tags, regions, store_type, edit_token_hash,
+            moderation_status
+        ) VALUES `)
+
+	args := make([]interface{}, 0, len(indexes)*columns)
+	for n, i := range indexes {
+		if n > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for col := 1; col <= columns; col++ {
+			if col > 1 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", n*columns+col)
+		}
+		query.WriteString(")")
+
+		coupon := coupons[i]
+		args = append(args,
+			coupon.Code, coupon.Title, coupon.Description,
+			coupon.DiscountValue, coupon.DiscountType,
+			coupon.MerchantName, coupon.MerchantURL,
+			coupon.StartDate, coupon.EndDate,
+			coupon.TermsConditions, coupon.MinimumPurchaseAmount,
+			coupon.MaximumDiscountAmount, pq.Array(coupon.Categories),
+			pq.Array(coupon.Tags), pq.Array(coupon.Regions),
+			coupon.StoreType, sql.NullString{String: coupon.EditTokenHash, Valid: coupon.EditTokenHash != ""},
+			moderationStatus(&coupon),
+		)
+	}
+	// The order of the returned rows is not guaranteed, they are matched to the coupons by their duplicate key
+	query.WriteString(` RETURNING merchant_domain || ':' || upper(btrim(code)), id, created_at, updated_at, materialized_score`)
+
+	byKey := make(map[string]int, len(indexes))
+	for _, i := range indexes {
+		byKey[keys[i]] = i
+	}
+
+	rows, err := tx.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var key string
+		var inserted models.Coupon
+		if err := rows.Scan(&key, &inserted.ID, &inserted.CreatedAt, &inserted.UpdatedAt, &inserted.MaterializedScore); err != nil {
+			return err
+		}
+		i, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("inserted coupon %d does not match any row of the batch", inserted.ID)
+		}
+		delete(byKey, key)
+
+		coupon := &coupons[i]
+		coupon.ID, coupon.CreatedAt, coupon.UpdatedAt, coupon.MaterializedScore = inserted.ID, inserted.CreatedAt, inserted.UpdatedAt, inserted.MaterializedScore
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if n != len(indexes) {
+		return fmt.Errorf("inserted %d coupons, expected %d", n, len(indexes))
+	}
+	return nil
+}
@@ -19,292 +19,364 @@ func NewCouponRepository(db *sql.DB) *CouponRepository {
 	return &CouponRepository{db: db}
 }
 
-// Migration SQL to create the table
-const createTableSQL = `
-CREATE TABLE IF NOT EXISTS coupons (
-    id BIGSERIAL PRIMARY KEY,
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    code VARCHAR(255) NOT NULL,
-    title VARCHAR(255) NOT NULL,
-    description TEXT,
-    discount_value DECIMAL(10,2) NOT NULL,
-    discount_type VARCHAR(50) NOT NULL,
-    merchant_name VARCHAR(255) NOT NULL,
-    merchant_url TEXT NOT NULL,
-    
-    start_date TIMESTAMP,
-    end_date TIMESTAMP,
-    terms_conditions TEXT,
-    minimum_purchase_amount DECIMAL(10,2),
-    maximum_discount_amount DECIMAL(10,2),
-    
-    up_votes TIMESTAMP[] DEFAULT ARRAY[]::TIMESTAMP[],
-    down_votes TIMESTAMP[] DEFAULT ARRAY[]::TIMESTAMP[],
-    
-    categories TEXT[] DEFAULT ARRAY[]::TEXT[],
-    tags TEXT[] DEFAULT ARRAY[]::TEXT[],
-    regions TEXT[] DEFAULT ARRAY[]::TEXT[],
-    store_type VARCHAR(50),
-    
-    materialized_score DECIMAL(10,4),
-    last_score_update TIMESTAMP,
-    
-    CONSTRAINT valid_discount_type CHECK (
-        discount_type IN ('PERCENTAGE_OFF', 'FIXED_AMOUNT', 'BOGO', 'FREE_SHIPPING')
-    ),
-    CONSTRAINT valid_store_type CHECK (
-        store_type IN ('online', 'in_store', 'both')
-    )
-);
-
--- Create indexes for common queries
-CREATE INDEX IF NOT EXISTS idx_coupons_merchant ON coupons(merchant_name);
-CREATE INDEX IF NOT EXISTS idx_coupons_created_at ON coupons(created_at);
-CREATE INDEX IF NOT EXISTS idx_coupons_code ON coupons(code);
-CREATE INDEX IF NOT EXISTS idx_coupons_score ON coupons(materialized_score);
-
-CREATE OR REPLACE FUNCTION calculate_coupon_score(
-    p_discount_value DECIMAL,
-    p_discount_type VARCHAR,
-    p_maximum_discount_amount DECIMAL,
-    p_created_at TIMESTAMP,
-    p_up_votes TIMESTAMP[],
-    p_down_votes TIMESTAMP[]
-) RETURNS DECIMAL AS $$
-DECLARE
-    vote_score DECIMAL;
-    discount_score DECIMAL;
-    freshness_score DECIMAL;
-BEGIN
-    -- Calculate vote score
-    SELECT COALESCE(
-        SUM(
-            CASE 
-                WHEN age < INTERVAL '1 day' THEN 1.0
-                WHEN age < INTERVAL '1 week' THEN 0.8
-                WHEN age < INTERVAL '1 month' THEN 0.6
-                WHEN age < INTERVAL '6 months' THEN 0.4
-                ELSE 0.2
-            END
-        ), 0) INTO vote_score
-    FROM (
-        SELECT CURRENT_TIMESTAMP - unnest(p_up_votes) as age
-    ) up;
-    
-    SELECT vote_score - COALESCE(
-        SUM(
-            CASE 
-                WHEN age < INTERVAL '1 day' THEN 1.0
-                WHEN age < INTERVAL '1 week' THEN 0.8
-                WHEN age < INTERVAL '1 month' THEN 0.6
-                WHEN age < INTERVAL '6 months' THEN 0.4
-                ELSE 0.2
-            END
-        ), 0) INTO vote_score
-    FROM (
-        SELECT CURRENT_TIMESTAMP - unnest(p_down_votes) as age
-    ) down;
-
-    -- Calculate discount score
-    discount_score := CASE 
-        WHEN p_discount_type = 'PERCENTAGE_OFF' THEN 
-            LEAST(p_discount_value / 100.0, 1.0)
-        WHEN p_discount_type = 'FIXED_AMOUNT' THEN 
-            CASE 
-                WHEN p_maximum_discount_amount > 0 THEN 
-                    LEAST(p_discount_value / p_maximum_discount_amount, 1.0)
-                ELSE 
-                    LEAST(p_discount_value / 1000.0, 1.0)
-            END
-        WHEN p_discount_type IN ('BOGO', 'FREE_SHIPPING') THEN 
-            0.5
-    END;
-
-    -- Calculate freshness score
-    freshness_score := CASE 
-        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 day' THEN 1.0
-        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 week' THEN 0.8
-        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '1 month' THEN 0.6
-        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '3 months' THEN 0.4
-        WHEN CURRENT_TIMESTAMP - p_created_at < INTERVAL '6 months' THEN 0.2
-        ELSE 0.1
-    END;
-
-    -- Return weighted score
-    RETURN (vote_score * 0.4) + (discount_score * 0.4) + (freshness_score * 0.2);
-END;
-$$ LANGUAGE plpgsql;
-
--- Create batch update function
-CREATE OR REPLACE FUNCTION update_materialized_scores_batch(batch_size INT) 
-RETURNS void AS $$
-BEGIN
-    WITH coupons_to_update AS (
-        SELECT id, discount_value, discount_type, maximum_discount_amount, 
-               created_at, up_votes, down_votes
-        FROM coupons 
-        WHERE last_score_update IS NULL
-        OR last_score_update < CURRENT_TIMESTAMP - INTERVAL '1 hour'
-        ORDER BY last_score_update NULLS FIRST 
-        LIMIT batch_size
-        FOR UPDATE SKIP LOCKED
-    )
-    UPDATE coupons c
-    SET materialized_score = calculate_coupon_score(
-            ct.discount_value,
-            ct.discount_type,
-            ct.maximum_discount_amount,
-            ct.created_at,
-            ct.up_votes,
-            ct.down_votes
-        ),
-        last_score_update = CURRENT_TIMESTAMP
-    FROM coupons_to_update ct
-    WHERE c.id = ct.id;
-END;
-$$ LANGUAGE plpgsql;
-
--- Create trigger function
-CREATE OR REPLACE FUNCTION update_coupon_score() RETURNS TRIGGER AS $$
-BEGIN
-    NEW.materialized_score := calculate_coupon_score(
-        NEW.discount_value,
-        NEW.discount_type,
-        NEW.maximum_discount_amount,
-        NEW.created_at,
-        NEW.up_votes,
-        NEW.down_votes
-    );
-    NEW.last_score_update := CURRENT_TIMESTAMP;
-    RETURN NEW;
-END;
-$$ LANGUAGE plpgsql;
-
--- Create trigger
-DROP TRIGGER IF EXISTS update_score_trigger ON coupons;
-CREATE TRIGGER update_score_trigger
-    BEFORE INSERT OR UPDATE OF discount_value, discount_type, maximum_discount_amount, up_votes, down_votes
-    ON coupons
-    FOR EACH ROW
-    EXECUTE FUNCTION update_coupon_score();
-`
-
-func (r *CouponRepository) CreateTable(ctx context.Context) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
-	}
-
-	// Execute the entire script
-	_, err = tx.ExecContext(ctx, createTableSQL)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to create tables and functions: %v", err)
-	}
-
-	return tx.Commit()
+// voteStatsJoin aggregates the votes of each selected coupon, recent votes are the ones of the last 7 days
+const voteStatsJoin = `
+        CROSS JOIN LATERAL (
+            SELECT
+                COUNT(*) FILTER (WHERE direction = 'up') AS up,
+                COUNT(*) FILTER (WHERE direction = 'down') AS down,
+                COUNT(*) FILTER (WHERE direction = 'up' AND created_at > CURRENT_TIMESTAMP - INTERVAL '7 days') AS recent_up,
+                COUNT(*) FILTER (WHERE direction = 'down' AND created_at > CURRENT_TIMESTAMP - INTERVAL '7 days') AS recent_down
+            FROM coupon_votes
+            WHERE coupon_id = coupons.id
+        ) AS votes`
+
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 func (r *CouponRepository) Create(ctx context.Context, coupon *models.Coupon) error {
+	return insertCoupon(ctx, r.db, coupon)
+}
+
+func insertCoupon(ctx context.Context, q queryRower, coupon *models.Coupon) error {
 	const query = `
         INSERT INTO coupons (
             code, title, description, discount_value, discount_type,
             merchant_name, merchant_url, start_date, end_date,
             terms_conditions, minimum_purchase_amount, maximum_discount_amount,
-            up_votes, down_votes, categories, tags, regions, store_type
+            categories, tags, regions, store_type, edit_token_hash,
+            moderation_status
         ) VALUES (
             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12,
             $13, $14, $15, $16, $17, $18
-        ) RETURNING id, created_at, materialized_score`
+        ) RETURNING id, created_at, updated_at, materialized_score`
 
-	return r.db.QueryRowContext(ctx, query,
+	return q.QueryRowContext(ctx, query,
 		coupon.Code, coupon.Title, coupon.Description,
 		coupon.DiscountValue, coupon.DiscountType,
 		coupon.MerchantName, coupon.MerchantURL,
 		coupon.StartDate, coupon.EndDate,
 		coupon.TermsConditions, coupon.MinimumPurchaseAmount,
-		coupon.MaximumDiscountAmount, &coupon.UpVotes,
-		&coupon.DownVotes, pq.Array(coupon.Categories),
+		coupon.MaximumDiscountAmount, pq.Array(coupon.Categories),
 		pq.Array(coupon.Tags), pq.Array(coupon.Regions),
-		coupon.StoreType,
-	).Scan(&coupon.ID, &coupon.CreatedAt, &coupon.MaterializedScore)
+		coupon.StoreType, coupon.EditTokenHash,
+		moderationStatus(coupon),
+	).Scan(&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt, &coupon.MaterializedScore)
+}
+
+// moderationStatus returns the moderation state a coupon is stored with, pending unless set
+func moderationStatus(coupon *models.Coupon) string {
+	if coupon.ModerationStatus == "" {
+		return models.ModerationPending
+	}
+	return coupon.ModerationStatus
+}
+
+// DuplicateMode decides what happens when a submitted coupon already exists
+type DuplicateMode string
+
+const (
+	DuplicateReject DuplicateMode = "reject"
+	DuplicateMerge  DuplicateMode = "merge"
+)
+
+// DuplicateCouponError is returned when the same code already exists for the same merchant domain
+type DuplicateCouponError struct {
+	ExistingID int64
+}
+
+func (e *DuplicateCouponError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *DuplicateCouponError) Error() string {
+	return fmt.Sprintf("coupon already exists with id %d", e.ExistingID)
+}
+
+// CreateUnique inserts the coupon unless the same code (ignoring case) already exists for the same merchant domain.
+// Rejected and hidden coupons do not count, so they cannot block a legitimate submission.
+// In DuplicateReject mode a *DuplicateCouponError is returned for duplicates. In DuplicateMerge mode the categories,
+// tags, regions and dates of the new coupon are merged into the existing one, coupon is filled with the existing
+// row's id, merchant name and status and merged is true. Merging is only allowed for approved coupons, i.e. those
+// of trusted API keys, and for the submitter of the existing coupon, proven by ownerTokenHash matching its edit
+// token. Anyone else gets a *DuplicateCouponError as well. Unless coupon is approved itself, the merged changes
+// send the existing coupon back to moderation.
+func (r *CouponRepository) CreateUnique(ctx context.Context, coupon *models.Coupon, mode DuplicateMode, ownerTokenHash string) (merged bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Serialize submissions for the same merchant domain so concurrent requests cannot both insert a code
+	_, err = tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(`+duplicateLockClass+`, hashtext(normalize_merchant_domain($1)) & `+duplicateLockMask+`)`,
+		coupon.MerchantURL,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	var existingID int64
+	var owned bool
+	err = tx.QueryRowContext(ctx, `
+        SELECT id, COALESCE($3::text <> '' AND edit_token_hash = $3::text, false) FROM coupons
+        WHERE merchant_domain = normalize_merchant_domain($1) AND upper(btrim(code)) = upper(btrim($2))
+            AND moderation_status IN ('pending', 'approved')
+        ORDER BY id
+        LIMIT 1`,
+		coupon.MerchantURL, coupon.Code, ownerTokenHash,
+	).Scan(&existingID, &owned)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if err = insertCoupon(ctx, tx, coupon); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+
+	case err != nil:
+		return false, err
+
+	case mode != DuplicateMerge, !owned && moderationStatus(coupon) != models.ModerationApproved:
+		err = &DuplicateCouponError{ExistingID: existingID}
+		return false, err
+	}
+
+	const mergeQuery = `
+        UPDATE coupons SET
+            categories = ARRAY(SELECT DISTINCT unnest(categories || $2::text[]) ORDER BY 1),
+            tags = ARRAY(SELECT DISTINCT unnest(tags || $3::text[]) ORDER BY 1),
+            regions = ARRAY(SELECT DISTINCT unnest(regions || $4::text[]) ORDER BY 1),
+            start_date = COALESCE($5, start_date),
+            end_date = COALESCE($6, end_date),
+            moderation_status = CASE WHEN $7::text = 'approved' THEN moderation_status ELSE $7::text END
+        WHERE id = $1
+        RETURNING id, created_at, updated_at, materialized_score, merchant_name, moderation_status`
+
+	err = tx.QueryRowContext(ctx, mergeQuery,
+		existingID, pq.Array(coupon.Categories), pq.Array(coupon.Tags),
+		pq.Array(coupon.Regions), coupon.StartDate, coupon.EndDate, moderationStatus(coupon),
+	).Scan(&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt, &coupon.MaterializedScore, &coupon.MerchantName, &coupon.ModerationStatus)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Update overwrites the editable fields of a coupon if editTokenHash matches the one stored on creation.
+// The coupon gets coupon.ModerationStatus, unless a moderator hid it.
+// Returns ErrInvalidEditToken if the token does not match an approved coupon and ErrCouponNotFound otherwise.
+func (r *CouponRepository) Update(ctx context.Context, coupon *models.Coupon, editTokenHash string) error {
+	// The score columns are always part of the SET list, so update_score_trigger recomputes the score
+	const query = `
+        UPDATE coupons SET
+            code = $3, title = $4, description = $5,
+            discount_value = $6, discount_type = $7,
+            merchant_name = $8, merchant_url = $9,
+            start_date = $10, end_date = $11, terms_conditions = $12,
+            minimum_purchase_amount = $13, maximum_discount_amount = $14,
+            categories = $15, tags = $16, regions = $17, store_type = $18,
+            moderation_status = CASE WHEN moderation_status = 'hidden' THEN moderation_status ELSE $19 END
+        WHERE id = $1 AND edit_token_hash = $2
+        RETURNING updated_at, materialized_score, last_score_update, moderation_status`
+
+	err := r.db.QueryRowContext(ctx, query,
+		coupon.ID, editTokenHash,
+		coupon.Code, coupon.Title, coupon.Description,
+		coupon.DiscountValue, coupon.DiscountType,
+		coupon.MerchantName, coupon.MerchantURL,
+		coupon.StartDate, coupon.EndDate, coupon.TermsConditions,
+		coupon.MinimumPurchaseAmount, coupon.MaximumDiscountAmount,
+		pq.Array(coupon.Categories), pq.Array(coupon.Tags),
+		pq.Array(coupon.Regions), coupon.StoreType, moderationStatus(coupon),
+	).Scan(&coupon.UpdatedAt, &coupon.MaterializedScore, &coupon.LastScoreUpdate, &coupon.ModerationStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.editTokenMismatch(ctx, coupon.ID)
+	}
+	return err
+}
+
+// Delete removes a coupon if editTokenHash matches the one stored on creation and returns its merchant name.
+// Returns ErrInvalidEditToken if the token does not match an approved coupon and ErrCouponNotFound otherwise.
+func (r *CouponRepository) Delete(ctx context.Context, id int64, editTokenHash string) (merchantName string, err error) {
+	err = r.db.QueryRowContext(ctx,
+		`DELETE FROM coupons WHERE id = $1 AND edit_token_hash = $2 RETURNING merchant_name`,
+		id, editTokenHash,
+	).Scan(&merchantName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", r.editTokenMismatch(ctx, id)
+	}
+	return merchantName, err
 }
 
+// editTokenMismatch explains why an update or delete guarded by the edit token matched no row.
+// Only approved coupons are public, the existence of any other coupon is not revealed without its token.
+func (r *CouponRepository) editTokenMismatch(ctx context.Context, id int64) error {
+	var approved bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM coupons WHERE id = $1 AND moderation_status = 'approved')`, id,
+	).Scan(&approved)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return ErrCouponNotFound
+	}
+	return ErrInvalidEditToken
+}
+
+// GetByID returns an approved coupon, ErrCouponNotFound if it does not exist or is not approved
 func (r *CouponRepository) GetByID(ctx context.Context, id int64) (*models.Coupon, error) {
+	return r.getByID(ctx, id, true, "")
+}
+
+// GetByEditToken returns a coupon regardless of its moderation state if editTokenHash matches the one stored on
+// creation. Returns ErrCouponNotFound otherwise, so the coupon's existence is only revealed to its submitter.
+func (r *CouponRepository) GetByEditToken(ctx context.Context, id int64, editTokenHash string) (*models.Coupon, error) {
+	if editTokenHash == "" {
+		return nil, ErrCouponNotFound
+	}
+	return r.getByID(ctx, id, false, editTokenHash)
+}
+
+// getByID returns a coupon, only if it is approved or only if its edit token matches if requested
+func (r *CouponRepository) getByID(ctx context.Context, id int64, approvedOnly bool, editTokenHash string) (*models.Coupon, error) {
 	const query = `
         SELECT 
-            id, created_at, code, title, description,
+            id, created_at, updated_at, code, title, description,
             discount_value, discount_type, merchant_name, merchant_url,
             start_date, end_date, terms_conditions,
             minimum_purchase_amount, maximum_discount_amount,
-            up_votes, down_votes, categories, tags,
-            regions, store_type, materialized_score,
-            last_score_update
-        FROM coupons 
-        WHERE id = $1`
+            votes.up, votes.down, votes.recent_up, votes.recent_down,
+            categories, tags, regions, store_type, materialized_score,
+            last_score_update, moderation_status, COALESCE(moderation_reason, '')
+        FROM coupons` + voteStatsJoin + `
+        WHERE id = $1 AND (NOT $2 OR moderation_status = 'approved') AND ($3::text = '' OR edit_token_hash = $3::text)`
 
 	coupon := &models.Coupon{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&coupon.ID, &coupon.CreatedAt, &coupon.Code,
+	err := r.db.QueryRowContext(ctx, query, id, approvedOnly, editTokenHash).Scan(
+		&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt, &coupon.Code,
 		&coupon.Title, &coupon.Description, &coupon.DiscountValue,
 		&coupon.DiscountType, &coupon.MerchantName, &coupon.MerchantURL,
 		&coupon.StartDate, &coupon.EndDate, &coupon.TermsConditions,
 		&coupon.MinimumPurchaseAmount, &coupon.MaximumDiscountAmount,
-		&coupon.UpVotes, &coupon.DownVotes,
+		&coupon.Votes.Up, &coupon.Votes.Down, &coupon.Votes.RecentUp, &coupon.Votes.RecentDown,
 		pq.Array(&coupon.Categories), pq.Array(&coupon.Tags),
 		pq.Array(&coupon.Regions), &coupon.StoreType,
 		&coupon.MaterializedScore, &coupon.LastScoreUpdate,
+		&coupon.ModerationStatus, &coupon.ModerationReason,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
-	return coupon, err
+	coupon.IsActive = coupon.IsActiveAt(time.Now())
+	return coupon, nil
 }
 
-func (r *CouponRepository) BatchAddVotes(ctx context.Context, votes []models.Vote, voteType string) error {
-	const upQuery = `
-        UPDATE coupons AS c
-        SET up_votes = CASE 
-            WHEN v.id = c.id THEN array_append(c.up_votes, v.timestamp)
-            ELSE c.up_votes
-        END
-        FROM (SELECT unnest($1::bigint[]) AS id, unnest($2::timestamp[]) AS timestamp) AS v
-        WHERE c.id = v.id`
-
-	const downQuery = `
-        UPDATE coupons AS c
-        SET down_votes = CASE 
-            WHEN v.id = c.id THEN array_append(c.down_votes, v.timestamp)
-            ELSE c.down_votes
-        END
-        FROM (SELECT unnest($1::bigint[]) AS id, unnest($2::timestamp[]) AS timestamp) AS v
-        WHERE c.id = v.id`
-
-	ids := make([]int64, len(votes))
-	timestamps := make([]time.Time, len(votes))
+// ApplyVotes stores, changes and withdraws votes. Each voter has at most one vote per coupon, repeating the same
+// vote changes nothing. Votes for coupons that no longer exist are skipped. If a batch contains several votes of the
+// same voter for the same coupon, only the last one is applied. The voted coupons are returned, as their scores
+// were recomputed.
+func (r *CouponRepository) ApplyVotes(ctx context.Context, votes []models.Vote) ([]RescoredCoupon, error) {
+	// The coupon_votes triggers recompute the scores of the affected coupons once per statement
+	const upsertQuery = `
+        INSERT INTO coupon_votes (coupon_id, direction, created_at, voter_hash)
+        SELECT v.id, v.direction, v.timestamp, NULLIF(v.voter_hash, '')
+        FROM unnest($1::bigint[], $2::text[], $3::timestamp[], $4::text[]) AS v(id, direction, timestamp, voter_hash)
+        WHERE EXISTS (SELECT 1 FROM coupons c WHERE c.id = v.id)
+        ON CONFLICT (coupon_id, voter_hash) DO UPDATE
+        SET direction = EXCLUDED.direction, created_at = EXCLUDED.created_at
+        WHERE coupon_votes.direction <> EXCLUDED.direction
+          AND coupon_votes.created_at < EXCLUDED.created_at`
+
+	const withdrawQuery = `
+        DELETE FROM coupon_votes cv
+        USING unnest($1::bigint[], $2::text[]) AS w(id, voter_hash)
+        WHERE cv.coupon_id = w.id AND cv.voter_hash = w.voter_hash`
+
+	// Keep only the last vote per voter and coupon, a single upsert cannot touch the same row twice
+	type voterKey struct {
+		id        int64
+		voterHash string
+	}
+	latest := make(map[voterKey]int, len(votes))
 	for i, v := range votes {
-		ids[i] = v.ID
-		timestamps[i] = v.Timestamp
+		if v.VoterHash != "" {
+			latest[voterKey{v.ID, v.VoterHash}] = i
+		}
 	}
 
-	query := upQuery
-	if voteType == "down" {
-		query = downQuery
+	var ids, withdrawIDs []int64
+	var directions, voterHashes, withdrawVoterHashes []string
+	var timestamps []time.Time
+	for i, v := range votes {
+		if v.VoterHash != "" && latest[voterKey{v.ID, v.VoterHash}] != i {
+			continue
+		}
+
+		switch v.Direction {
+		case models.VoteUp, models.VoteDown:
+			ids = append(ids, v.ID)
+			directions = append(directions, v.Direction)
+			timestamps = append(timestamps, v.Timestamp)
+			voterHashes = append(voterHashes, v.VoterHash)
+		case models.VoteWithdraw:
+			if v.VoterHash != "" {
+				withdrawIDs = append(withdrawIDs, v.ID)
+				withdrawVoterHashes = append(withdrawVoterHashes, v.VoterHash)
+			}
+		}
 	}
 
-	result, err := r.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(timestamps))
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	if len(ids) > 0 {
+		_, err := tx.ExecContext(ctx, upsertQuery,
+			pq.Array(ids), pq.Array(directions), pq.Array(timestamps), pq.Array(voterHashes),
+		)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if len(withdrawIDs) > 0 {
+		_, err := tx.ExecContext(ctx, withdrawQuery, pq.Array(withdrawIDs), pq.Array(withdrawVoterHashes))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 
-	rows, err := result.RowsAffected()
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, merchant_name FROM coupons WHERE id = ANY($1::bigint[]) OR id = ANY($2::bigint[])`,
+		pq.Array(ids), pq.Array(withdrawIDs),
+	)
 	if err != nil {
-		return err
+		tx.Rollback()
+		return nil, err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+	coupons, err := scanRescoredCoupons(rows)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-	return nil
+
+	return coupons, tx.Commit()
 }
 
 // --- Search and Filtering ---
@@ -317,85 +389,227 @@ const (
 	SortByOldest    SortBy = "oldest"
 	SortByHighScore SortBy = "high_score"
 	SortByLowScore  SortBy = "low_score"
+	SortByRelevance SortBy = "relevance"
 )
 
+// MatchMode controls how multiple values of an array filter (categories, tags, regions) are combined
+type MatchMode string
+
+const (
+	MatchAny MatchMode = "any"
+	MatchAll MatchMode = "all"
+)
+
+// Validity selects coupons by their start_date/end_date validity window
+type Validity string
+
+const (
+	ValidityActive   Validity = "true"
+	ValidityInactive Validity = "false"
+	ValidityAll      Validity = "all"
+)
+
+// activeCondition matches coupons that have started and not yet expired, open ended dates always match
+const activeCondition = `(start_date IS NULL OR start_date <= CURRENT_TIMESTAMP) AND (end_date IS NULL OR end_date > CURRENT_TIMESTAMP)`
+
 // SearchParams contains all parameters for searching and filtering coupons
 type SearchParams struct {
+	// SearchString is matched with full-text search (see buildTSQuery),
+	// unless SearchIn is set, in which case the listed columns are matched by substring.
 	SearchString string
 	SortBy       SortBy
 	Limit        int
 	Offset       int
 	SearchIn     []string
+
+	// Cursor continues keyset pagination after the last row of a previous page, it must match SortBy
+	Cursor *Cursor
+	// SkipTotal tells callers to not run GetTotalCount, e.g. for infinite scrolling
+	SkipTotal bool
+
+	// Filters, values within a filter are OR-ed, different filters are AND-ed.
+	// Array filters use Match to decide whether any or all values must be present.
+	Merchants     []string
+	Categories    []string
+	Tags          []string
+	Regions       []string
+	DiscountTypes []string
+	StoreTypes    []string
+	Match         MatchMode
+
+	// Validity window, the zero value only returns currently active coupons
+	Active        Validity
+	ExpiresBefore *time.Time // only coupons with an end_date before this time
+	ExpiresAfter  *time.Time // only coupons that are still valid after this time (including open ended ones)
 }
 
-func (r *CouponRepository) Search(ctx context.Context, params SearchParams) ([]models.Coupon, error) {
-	// Base query
-	query := `
-        SELECT 
-            id, created_at, code, title, description,
-            discount_value, discount_type, merchant_name, merchant_url,
-            start_date, end_date, terms_conditions,
-            minimum_purchase_amount, maximum_discount_amount,
-            up_votes, down_votes, categories, tags,
-            regions, store_type, materialized_score,
-            last_score_update
-        FROM coupons
-        WHERE 1=1
-    `
-
-	// Initialize parameters array and counter
+// buildWhereClause returns the conditions and parameters shared by Search and GetTotalCount
+func buildWhereClause(params SearchParams) (string, []interface{}) {
+	where := ` WHERE moderation_status = 'approved'`
 	queryParams := make([]interface{}, 0)
 	paramCounter := 1
 
-	// Add search condition if search string is provided
-	if params.SearchString != "" && len(params.SearchIn) > 0 {
-		/*
-					query += fmt.Sprintf(`
-			            AND (
-			                code ILIKE $%d OR
-			                title ILIKE $%d OR
-			                description ILIKE $%d OR
-			                merchant_name ILIKE $%d OR
-							merchant_url ILIKE $%d
-			            )`, paramCounter, paramCounter, paramCounter, paramCounter, paramCounter)
-		*/
-		query += `AND (`
+	// Add search condition if search string is provided, SearchIn falls back to substring matching on the given columns
+	if tsQuery := buildTSQuery(params.SearchString); tsQuery != "" && len(params.SearchIn) == 0 {
+		where += fmt.Sprintf(` AND search_vector @@ to_tsquery('%s', $%d)`, searchConfig, paramCounter)
+		queryParams = append(queryParams, tsQuery)
+		paramCounter++
+	} else if params.SearchString != "" && len(params.SearchIn) > 0 {
+		where += ` AND (`
 
 		for i, searchIn := range params.SearchIn {
 			if i > 0 {
-				query += " OR "
+				where += " OR "
 			}
-			query += fmt.Sprintf(`%s ILIKE $%d`, searchIn, paramCounter)
+			where += fmt.Sprintf(`%s ILIKE $%d`, searchIn, paramCounter)
 		}
 
-		query += `)`
+		where += `)`
 
 		searchTerm := "%" + params.SearchString + "%"
 		queryParams = append(queryParams, searchTerm)
 		paramCounter++
 	}
 
+	// Scalar columns can only hold one value, so multiple values are always OR-ed
+	scalarFilters := []struct {
+		column string
+		values []string
+	}{
+		{"merchant_name", params.Merchants},
+		{"discount_type", params.DiscountTypes},
+		{"store_type", params.StoreTypes},
+	}
+	for _, filter := range scalarFilters {
+		if len(filter.values) == 0 {
+			continue
+		}
+		where += fmt.Sprintf(` AND %s = ANY($%d)`, filter.column, paramCounter)
+		queryParams = append(queryParams, pq.Array(filter.values))
+		paramCounter++
+	}
+
+	// Array columns are backed by GIN indexes which support both && (any) and @> (all)
+	arrayOperator := "&&"
+	if params.Match == MatchAll {
+		arrayOperator = "@>"
+	}
+	arrayFilters := []struct {
+		column string
+		values []string
+	}{
+		{"categories", params.Categories},
+		{"tags", params.Tags},
+		{"regions", params.Regions},
+	}
+	for _, filter := range arrayFilters {
+		if len(filter.values) == 0 {
+			continue
+		}
+		where += fmt.Sprintf(` AND %s %s $%d::text[]`, filter.column, arrayOperator, paramCounter)
+		queryParams = append(queryParams, pq.Array(filter.values))
+		paramCounter++
+	}
+
+	switch params.Active {
+	case ValidityAll:
+	case ValidityInactive:
+		where += ` AND NOT (` + activeCondition + `)`
+	default:
+		where += ` AND ` + activeCondition
+	}
+
+	if params.ExpiresBefore != nil {
+		where += fmt.Sprintf(` AND end_date < $%d`, paramCounter)
+		queryParams = append(queryParams, *params.ExpiresBefore)
+		paramCounter++
+	}
+	if params.ExpiresAfter != nil {
+		where += fmt.Sprintf(` AND (end_date IS NULL OR end_date > $%d)`, paramCounter)
+		queryParams = append(queryParams, *params.ExpiresAfter)
+		paramCounter++
+	}
+
+	return where, queryParams
+}
+
+// rankedSearch reports whether the search is sorted by the rank of a full-text query
+func rankedSearch(params SearchParams) bool {
+	return params.SortBy == SortByRelevance && len(params.SearchIn) == 0 && buildTSQuery(params.SearchString) != ""
+}
+
+// searchSortKey returns the sort key used for the given search parameters
+func searchSortKey(params SearchParams, tsQueryParam int) sortKey {
 	switch params.SortBy {
-	case SortByNewest:
-		query += ` ORDER BY created_at DESC`
 	case SortByOldest:
-		query += ` ORDER BY created_at ASC`
+		return sortKey{expr: "created_at", cast: "timestamp"}
 	case SortByHighScore:
-		query += ` ORDER BY materialized_score  DESC`
+		return sortKey{expr: "COALESCE(materialized_score, 0)", cast: "numeric", desc: true}
 	case SortByLowScore:
-		query += ` ORDER BY materialized_score  ASC`
+		return sortKey{expr: "COALESCE(materialized_score, 0)", cast: "numeric"}
+	case SortByRelevance:
+		// Without a full-text query there is nothing to rank, so fall back to the newest coupons
+		if tsQueryParam > 0 {
+			return sortKey{
+				expr: fmt.Sprintf("ts_rank(search_vector, to_tsquery('%s', $%d))", searchConfig, tsQueryParam),
+				cast: "real",
+				desc: true,
+			}
+		}
+		return sortKey{expr: "created_at", cast: "timestamp", desc: true}
 	default:
-		query += ` ORDER BY created_at DESC`
+		return sortKey{expr: "created_at", cast: "timestamp", desc: true}
+	}
+}
+
+// Search returns a page of coupons matching params and, if there are more rows, the cursor of the next page
+func (r *CouponRepository) Search(ctx context.Context, params SearchParams) ([]models.Coupon, *Cursor, error) {
+	if params.Cursor != nil && params.Cursor.SortBy != params.SortBy {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	where, queryParams := buildWhereClause(params)
+	paramCounter := len(queryParams) + 1
+
+	// Ranking needs the full-text query a second time
+	tsQueryParam := 0
+	if rankedSearch(params) {
+		tsQueryParam = paramCounter
+		queryParams = append(queryParams, buildTSQuery(params.SearchString))
+		paramCounter++
+	}
+	key := searchSortKey(params, tsQueryParam)
+
+	// Base query
+	query := fmt.Sprintf(`
+        SELECT 
+            id, created_at, updated_at, code, title, description,
+            discount_value, discount_type, merchant_name, merchant_url,
+            start_date, end_date, terms_conditions,
+            minimum_purchase_amount, maximum_discount_amount,
+            votes.up, votes.down, votes.recent_up, votes.recent_down,
+            categories, tags, regions, store_type, materialized_score,
+            last_score_update, (%s)::text AS sort_key
+        FROM coupons`+voteStatsJoin, key.expr)
+	query += where
+
+	// Continue after the cursor instead of skipping rows
+	if params.Cursor != nil {
+		query += key.after(paramCounter, paramCounter+1)
+		queryParams = append(queryParams, params.Cursor.Key, params.Cursor.ID)
+		paramCounter += 2
 	}
 
-	// Add pagination
+	query += key.orderBy()
+
+	// Add pagination, fetching one extra row to know whether there is a next page
 	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, paramCounter, paramCounter+1)
-	queryParams = append(queryParams, params.Limit, params.Offset)
+	queryParams = append(queryParams, params.Limit+1, params.Offset)
 
 	// Execute query
 	rows, err := r.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
@@ -406,51 +620,50 @@ func (r *CouponRepository) Search(ctx context.Context, params SearchParams) ([]m
 
 	// Parse results
 	var coupons []models.Coupon
+	var lastSortKey string
+	var next *Cursor
+	now := time.Now()
 	for rows.Next() {
+		if len(coupons) == params.Limit {
+			last := coupons[len(coupons)-1]
+			next = &Cursor{SortBy: params.SortBy, Key: lastSortKey, ID: last.ID}
+			if tsQueryParam > 0 {
+				next.Query = queryHash(params.SearchString)
+			}
+			break
+		}
+
 		coupon := &models.Coupon{}
 		err := rows.Scan(
-			&coupon.ID, &coupon.CreatedAt, &coupon.Code,
+			&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt, &coupon.Code,
 			&coupon.Title, &coupon.Description, &coupon.DiscountValue,
 			&coupon.DiscountType, &coupon.MerchantName, &coupon.MerchantURL,
 			&coupon.StartDate, &coupon.EndDate, &coupon.TermsConditions,
 			&coupon.MinimumPurchaseAmount, &coupon.MaximumDiscountAmount,
-			&coupon.UpVotes, &coupon.DownVotes,
+			&coupon.Votes.Up, &coupon.Votes.Down, &coupon.Votes.RecentUp, &coupon.Votes.RecentDown,
 			pq.Array(&coupon.Categories), pq.Array(&coupon.Tags),
 			pq.Array(&coupon.Regions), &coupon.StoreType,
 			&coupon.MaterializedScore, &coupon.LastScoreUpdate,
+			&lastSortKey,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		coupon.IsActive = coupon.IsActiveAt(now)
 		coupons = append(coupons, *coupon)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
 
-	return coupons, nil
+	return coupons, next, nil
 }
 
 // GetTotalCount returns the total number of coupons matching the search criteria
 // This is useful for pagination
 func (r *CouponRepository) GetTotalCount(ctx context.Context, params SearchParams) (int64, error) {
-	query := `SELECT COUNT(*) FROM coupons WHERE 1=1`
-	queryParams := make([]interface{}, 0)
-	paramCounter := 1
-
-	if params.SearchString != "" && len(params.SearchIn) > 0 {
-		query += ` AND (`
-
-		for i, searchIn := range params.SearchIn {
-			if i > 0 {
-				query += " OR "
-			}
-			query += fmt.Sprintf(`%s ILIKE $%d`, searchIn, paramCounter)
-		}
-
-		query += `)`
-
-		searchTerm := "%" + params.SearchString + "%"
-		queryParams = append(queryParams, searchTerm)
-		paramCounter++
-	}
+	where, queryParams := buildWhereClause(params)
+	query := `SELECT COUNT(*) FROM coupons` + where
 
 	var count int64
 	err := r.db.QueryRowContext(ctx, query, queryParams...).Scan(&count)
@@ -465,6 +678,7 @@ func (r *CouponRepository) GetMerchants(ctx context.Context) (*models.MerchantRe
        merchant_name,
        ARRAY_AGG(DISTINCT merchant_url) as merchant_url
     FROM coupons
+    WHERE moderation_status = 'approved'
     GROUP BY merchant_name
     ORDER BY merchant_name;
 `
@@ -500,7 +714,7 @@ func (r *CouponRepository) GetMerchants(ctx context.Context) (*models.MerchantRe
 // --- Categories ---
 
 func (r *CouponRepository) GetCategories(ctx context.Context) (*models.CategoriesResponse, error) {
-	query := `SELECT DISTINCT unnest(categories) FROM coupons ORDER BY 1;`
+	query := `SELECT DISTINCT unnest(categories) FROM coupons WHERE moderation_status = 'approved' ORDER BY 1;`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -533,7 +747,7 @@ func (r *CouponRepository) GetCategories(ctx context.Context) (*models.Categorie
 // --- Tags ---
 
 func (r *CouponRepository) GetTags(ctx context.Context) (*models.TagResponse, error) {
-	query := `SELECT DISTINCT unnest(tags) FROM coupons ORDER BY 1;`
+	query := `SELECT DISTINCT unnest(tags) FROM coupons WHERE moderation_status = 'approved' ORDER BY 1;`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -566,7 +780,7 @@ func (r *CouponRepository) GetTags(ctx context.Context) (*models.TagResponse, er
 // --- Regions ---
 
 func (r *CouponRepository) GetRegions(ctx context.Context) (*models.RegionResponse, error) {
-	query := `SELECT DISTINCT unnest(regions) FROM coupons ORDER BY 1;`
+	query := `SELECT DISTINCT unnest(regions) FROM coupons WHERE moderation_status = 'approved' ORDER BY 1;`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
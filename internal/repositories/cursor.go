@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or does not match the sort order
+var ErrInvalidCursor error = &ValidationError{Field: "cursor", Message: "Invalid cursor"}
+
+// Cursor points at the last row of a page for keyset pagination.
+// Clients only ever see it in its opaque encoded form.
+type Cursor struct {
+	SortBy SortBy `json:"s"`
+	Key    string `json:"k"`           // sort key of the last row, as rendered by Postgres
+	ID     int64  `json:"i"`           // tie breaker for rows sharing the same sort key
+	Query  string `json:"q,omitempty"` // hash of the search string of relevance cursors, see queryHash
+}
+
+// queryHash identifies the search string a relevance cursor was issued for, as the ranking depends on it
+func queryHash(searchString string) string {
+	sum := sha256.Sum256([]byte(searchString))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Formats of sort keys as rendered by Postgres, a key is only passed to Postgres if it matches its type
+var (
+	numericKeyPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	realKeyPattern    = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?(e[+-]?[0-9]+)?$`)
+)
+
+const timestampKeyLayout = "2006-01-02 15:04:05.999999"
+
+// Encode returns the opaque string representation of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor previously returned by Cursor.Encode and checks that it belongs to the search
+// described by params. Returns ErrInvalidCursor if it is malformed or its key does not fit the sort order.
+func DecodeCursor(s string, params SearchParams) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID < 1 {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.SortBy != params.SortBy {
+		return nil, &ValidationError{Field: "cursor", Message: fmt.Sprintf("cursor was created for sort_by=%s", cursor.SortBy)}
+	}
+
+	key := searchSortKey(params, 0)
+	if rankedSearch(params) {
+		if cursor.Query != queryHash(params.SearchString) {
+			return nil, &ValidationError{Field: "cursor", Message: "cursor was created for a different search query"}
+		}
+		// The parameter number does not matter for the key type
+		key = searchSortKey(params, 1)
+	}
+	if !key.validKey(cursor.Key) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// sortKey describes the ordering of a search, which is always the key expression followed by the id
+type sortKey struct {
+	expr string // SQL expression of the primary sort key
+	cast string // type the cursor key is cast to before comparing it with expr
+	desc bool
+}
+
+// validKey reports whether a cursor key can be cast to the type of the sort key
+func (k sortKey) validKey(key string) bool {
+	switch k.cast {
+	case "timestamp":
+		_, err := time.Parse(timestampKeyLayout, key)
+		return err == nil
+	case "numeric":
+		return numericKeyPattern.MatchString(key)
+	case "real":
+		return realKeyPattern.MatchString(key)
+	default:
+		return false
+	}
+}
+
+// orderBy returns the ORDER BY clause for the sort key
+func (k sortKey) orderBy() string {
+	dir := "ASC"
+	if k.desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf(` ORDER BY %s %s, id %s`, k.expr, dir, dir)
+}
+
+// after returns the condition selecting the rows following the cursor, using the given parameter numbers
+func (k sortKey) after(keyParam, idParam int) string {
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+	return fmt.Sprintf(` AND (%s, id) %s ($%d::%s, $%d)`, k.expr, op, keyParam, k.cast, idParam)
+}
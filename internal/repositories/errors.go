@@ -0,0 +1,47 @@
+package repositories
+
+import "errors"
+
+// Sentinel errors describing why an operation failed, check them with errors.Is
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+)
+
+var (
+	ErrCouponNotFound   = &Error{Kind: ErrNotFound, Message: "Coupon not found"}
+	ErrInvalidEditToken = &Error{Kind: ErrForbidden, Message: "Invalid edit token"}
+	ErrAPIKeyNotFound   = &Error{Kind: ErrNotFound, Message: "API key not found"}
+	ErrJobNotFound      = &Error{Kind: ErrNotFound, Message: "Job not found"}
+	ErrJobRunning       = &Error{Kind: ErrConflict, Message: "Job is already running"}
+)
+
+// Error is an error of one of the sentinel kinds with a message that can be shown to clients
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// ValidationError describes an invalid input field, its message can be shown to clients
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"discountdb-api/internal/models"
+	"github.com/lib/pq"
+	"time"
+)
+
+// exportBatchSize is the number of coupons read per query by Export
+const exportBatchSize = 1000
+
+// ExportParams selects the coupons passed on by Export
+type ExportParams struct {
+	UpdatedSince *time.Time // only coupons changed at or after this time
+}
+
+// Export calls fn for every approved coupon, ordered by the time of their last change. The coupons are read in batches, so
+// neither the whole dataset nor a database connection is held while fn is busy. A coupon changed during the export
+// is passed on again with its new data.
+func (r *CouponRepository) Export(ctx context.Context, params ExportParams, fn func(coupon *models.Coupon) error) error {
+	const query = `
+        SELECT
+            id, created_at, updated_at, code, title, description,
+            discount_value, discount_type, merchant_name, merchant_url,
+            start_date, end_date, terms_conditions,
+            minimum_purchase_amount, maximum_discount_amount,
+            votes.up, votes.down, votes.recent_up, votes.recent_down,
+            categories, tags, regions, store_type, materialized_score,
+            last_score_update
+        FROM coupons` + voteStatsJoin + `
+        WHERE moderation_status = 'approved'
+          AND ($1::timestamp IS NULL OR updated_at >= $1)
+          AND ($2::timestamp IS NULL OR (updated_at, id) > ($2, $3))
+        ORDER BY updated_at, id
+        LIMIT $4`
+
+	// updated_at holds UTC without a time zone
+	var since, afterTime *time.Time
+	if params.UpdatedSince != nil {
+		utc := params.UpdatedSince.UTC()
+		since = &utc
+	}
+	var afterID int64
+
+	for {
+		coupons, err := r.exportBatch(ctx, query, since, afterTime, afterID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range coupons {
+			coupon := &coupons[i]
+			coupon.IsActive = coupon.IsActiveAt(now)
+			if err := fn(coupon); err != nil {
+				return err
+			}
+		}
+
+		if len(coupons) < exportBatchSize {
+			return nil
+		}
+		last := coupons[len(coupons)-1]
+		afterTime, afterID = &last.UpdatedAt, last.ID
+	}
+}
+
+func (r *CouponRepository) exportBatch(ctx context.Context, query string, since, afterTime *time.Time, afterID int64) ([]models.Coupon, error) {
+	rows, err := r.db.QueryContext(ctx, query, since, afterTime, afterID, exportBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := make([]models.Coupon, 0, exportBatchSize)
+	for rows.Next() {
+		var coupon models.Coupon
+		err := rows.Scan(
+			&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt, &coupon.Code,
+			&coupon.Title, &coupon.Description, &coupon.DiscountValue,
+			&coupon.DiscountType, &coupon.MerchantName, &coupon.MerchantURL,
+			&coupon.StartDate, &coupon.EndDate, &coupon.TermsConditions,
+			&coupon.MinimumPurchaseAmount, &coupon.MaximumDiscountAmount,
+			&coupon.Votes.Up, &coupon.Votes.Down, &coupon.Votes.RecentUp, &coupon.Votes.RecentDown,
+			pq.Array(&coupon.Categories), pq.Array(&coupon.Tags),
+			pq.Array(&coupon.Regions), &coupon.StoreType,
+			&coupon.MaterializedScore, &coupon.LastScoreUpdate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, rows.Err()
+}
+
+// ExportRemovals calls fn for every published coupon that was deleted or un-published at or after since, ordered by
+// the time of the removal. Incremental exports pass them on so copies of the dataset can drop these coupons.
+func (r *CouponRepository) ExportRemovals(ctx context.Context, since time.Time, fn func(removal *models.CouponRemoval) error) error {
+	const query = `
+        SELECT coupon_id, removed_at, reason
+        FROM coupon_removals
+        WHERE removed_at >= $1
+          AND ($2::timestamp IS NULL OR (removed_at, coupon_id) > ($2, $3))
+        ORDER BY removed_at, coupon_id
+        LIMIT $4`
+
+	// removed_at holds UTC without a time zone
+	since = since.UTC()
+	var afterTime *time.Time
+	var afterID int64
+
+	for {
+		removals, err := r.exportRemovalBatch(ctx, query, since, afterTime, afterID)
+		if err != nil {
+			return err
+		}
+
+		for i := range removals {
+			if err := fn(&removals[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(removals) < exportBatchSize {
+			return nil
+		}
+		last := removals[len(removals)-1]
+		afterTime, afterID = &last.RemovedAt, last.ID
+	}
+}
+
+func (r *CouponRepository) exportRemovalBatch(ctx context.Context, query string, since time.Time, afterTime *time.Time, afterID int64) ([]models.CouponRemoval, error) {
+	rows, err := r.db.QueryContext(ctx, query, since, afterTime, afterID, exportBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	removals := make([]models.CouponRemoval, 0, exportBatchSize)
+	for rows.Next() {
+		var removal models.CouponRemoval
+		if err := rows.Scan(&removal.ID, &removal.RemovedAt, &removal.Reason); err != nil {
+			return nil, err
+		}
+		removals = append(removals, removal)
+	}
+	return removals, rows.Err()
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchConfig is the text search configuration used for both the search_vector column and queries
+const searchConfig = "english"
+
+// buildTSQuery converts user input into an expression for to_tsquery.
+//
+// Supported syntax:
+//   - plain terms, all of which must match
+//   - "quoted phrases" whose words must appear next to each other
+//   - prefix* terms matching every word starting with prefix
+//   - -excluded terms that must not match
+//
+// Everything except letters and digits is stripped, so the result is always a valid tsquery.
+// An empty string is returned if the input contains no searchable words.
+func buildTSQuery(input string) string {
+	var parts []string
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+
+		case runes[i] == '"':
+			// Phrase: everything up to the closing quote (or the end of the input)
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if phrase := tsPhrase(string(runes[i+1:end]), false); phrase != "" {
+				parts = append(parts, phrase)
+			}
+			i = end + 1
+
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			term := string(runes[i:end])
+			i = end
+
+			negate := strings.HasPrefix(term, "-")
+			term = strings.TrimPrefix(term, "-")
+			prefix := strings.HasSuffix(term, "*")
+			term = strings.TrimSuffix(term, "*")
+
+			phrase := tsPhrase(term, prefix)
+			if phrase == "" {
+				continue
+			}
+			if negate {
+				phrase = "!" + phrase
+			}
+			parts = append(parts, phrase)
+		}
+	}
+
+	return strings.Join(parts, " & ")
+}
+
+// tsPhrase joins the words of s with the followed-by operator, optionally marking the last word as a prefix
+func tsPhrase(s string, prefix bool) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	if prefix {
+		words[len(words)-1] += ":*"
+	}
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " <-> ") + ")"
+}
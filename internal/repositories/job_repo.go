@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/models"
+	"errors"
+	"time"
+)
+
+type JobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// StartRun records the start of a run and fills in its id, status and start time. It returns false without
+// recording anything if the run's schedule slot was already run, e.g. by another replica.
+// Earlier runs of the job still marked as running were interrupted, the caller must hold the job's lock.
+func (r *JobRepository) StartRun(ctx context.Context, run *models.JobRun) (bool, error) {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE job_runs SET status = 'failed', error = 'interrupted', finished_at = CURRENT_TIMESTAMP
+        WHERE job_name = $1 AND status = 'running'`,
+		run.JobName,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+        INSERT INTO job_runs (job_name, trigger, scheduled_for, instance)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (job_name, scheduled_for) WHERE scheduled_for IS NOT NULL DO NOTHING
+        RETURNING id, status, started_at`,
+		run.JobName, run.Trigger, run.ScheduledFor, run.Instance,
+	).Scan(&run.ID, &run.Status, &run.StartedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FinishRun records the end of a run, it failed if errMessage is not empty
+func (r *JobRepository) FinishRun(ctx context.Context, id int64, duration time.Duration, errMessage string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE job_runs SET
+            status = CASE WHEN $3 = '' THEN 'succeeded' ELSE 'failed' END,
+            error = NULLIF($3, ''),
+            finished_at = CURRENT_TIMESTAMP,
+            duration_ms = $2
+        WHERE id = $1`,
+		id, duration.Milliseconds(), errMessage,
+	)
+	return err
+}
+
+// Runs returns the latest runs of a job, newest first
+func (r *JobRepository) Runs(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, job_name, trigger, scheduled_for, instance, status, COALESCE(error, ''),
+            started_at, finished_at, duration_ms
+        FROM job_runs
+        WHERE job_name = $1
+        ORDER BY started_at DESC, id DESC
+        LIMIT $2`,
+		jobName, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.JobRun{}
+	for rows.Next() {
+		var run models.JobRun
+		err := rows.Scan(
+			&run.ID, &run.JobName, &run.Trigger, &run.ScheduledFor, &run.Instance, &run.Status, &run.Error,
+			&run.StartedAt, &run.FinishedAt, &run.DurationMs,
+		)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// DeleteRunsBefore removes the finished runs that started more than the given number of days ago
+func (r *JobRepository) DeleteRunsBefore(ctx context.Context, days int) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        DELETE FROM job_runs
+        WHERE status <> 'running' AND started_at < CURRENT_TIMESTAMP - make_interval(days => $1)`,
+		days,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/models"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// Queue returns a page of coupons with the given moderation state, oldest first, and the number of such coupons
+func (r *ModerationRepository) Queue(ctx context.Context, status string, limit, offset int) ([]models.Coupon, int64, error) {
+	const query = `
+        SELECT
+            id, created_at, updated_at, code, title, description,
+            discount_value, discount_type, merchant_name, merchant_url,
+            start_date, end_date, terms_conditions,
+            minimum_purchase_amount, maximum_discount_amount,
+            votes.up, votes.down, votes.recent_up, votes.recent_down,
+            categories, tags, regions, store_type, materialized_score,
+            last_score_update, moderation_status, COALESCE(moderation_reason, '')
+        FROM coupons` + voteStatsJoin + `
+        WHERE moderation_status = $1
+        ORDER BY created_at, id
+        LIMIT $2 OFFSET $3`
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM coupons WHERE moderation_status = $1`, status).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	now := time.Now()
+	for rows.Next() {
+		var coupon models.Coupon
+		err := rows.Scan(
+			&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt, &coupon.Code,
+			&coupon.Title, &coupon.Description, &coupon.DiscountValue,
+			&coupon.DiscountType, &coupon.MerchantName, &coupon.MerchantURL,
+			&coupon.StartDate, &coupon.EndDate, &coupon.TermsConditions,
+			&coupon.MinimumPurchaseAmount, &coupon.MaximumDiscountAmount,
+			&coupon.Votes.Up, &coupon.Votes.Down, &coupon.Votes.RecentUp, &coupon.Votes.RecentDown,
+			pq.Array(&coupon.Categories), pq.Array(&coupon.Tags),
+			pq.Array(&coupon.Regions), &coupon.StoreType,
+			&coupon.MaterializedScore, &coupon.LastScoreUpdate,
+			&coupon.ModerationStatus, &coupon.ModerationReason,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		coupon.IsActive = coupon.IsActiveAt(now)
+		coupons = append(coupons, coupon)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return coupons, total, nil
+}
+
+// SetStatus changes the moderation state of a coupon and records the change in the moderation log.
+// apiKeyID identifies the moderator, nil for automatic changes. Returns the coupon's merchant name,
+// or ErrCouponNotFound if the coupon does not exist.
+func (r *ModerationRepository) SetStatus(ctx context.Context, id int64, status, reason string, apiKeyID *int64) (string, error) {
+	_, merchantName, err := r.setStatus(ctx, id, status, reason, apiKeyID, false)
+	return merchantName, err
+}
+
+// Flag automatically changes the moderation state of an approved coupon, e.g. after too many reports, and records
+// the change in the moderation log. changed is false if the coupon is not approved (anymore).
+// Returns ErrCouponNotFound if the coupon does not exist.
+func (r *ModerationRepository) Flag(ctx context.Context, id int64, status, reason string) (changed bool, merchantName string, err error) {
+	return r.setStatus(ctx, id, status, reason, nil, true)
+}
+
+func (r *ModerationRepository) setStatus(ctx context.Context, id int64, status, reason string, apiKeyID *int64, onlyApproved bool) (changed bool, merchantName string, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var previousStatus string
+	err = tx.QueryRowContext(ctx, `
+        WITH previous AS (
+            SELECT id, moderation_status FROM coupons WHERE id = $1 FOR UPDATE
+        )
+        UPDATE coupons c SET
+            moderation_status = $2,
+            moderation_reason = NULLIF($3, ''),
+            moderated_at = CURRENT_TIMESTAMP
+        FROM previous
+        WHERE c.id = previous.id AND (NOT $4 OR previous.moderation_status = 'approved')
+        RETURNING previous.moderation_status, c.merchant_name`,
+		id, status, reason, onlyApproved,
+	).Scan(&previousStatus, &merchantName)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !onlyApproved {
+			err = ErrCouponNotFound
+			return false, "", err
+		}
+
+		// Tell a missing coupon apart from one that is not approved
+		var exists bool
+		if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM coupons WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return false, "", err
+		}
+		if !exists {
+			err = ErrCouponNotFound
+			return false, "", err
+		}
+		return false, "", tx.Commit()
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO moderation_log (coupon_id, previous_status, status, reason, api_key_id)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5)`,
+		id, previousStatus, status, reason, apiKeyID,
+	)
+	if err != nil {
+		return false, "", err
+	}
+
+	return true, merchantName, tx.Commit()
+}
+
+// HideMerchant hides every visible or pending coupon of a merchant domain (see normalize_merchant_domain, a URL
+// works as well) and records each in the moderation log. Returns the ids and merchant names of the hidden coupons.
+func (r *ModerationRepository) HideMerchant(ctx context.Context, merchantDomain, reason string, apiKeyID *int64) ([]int64, []string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        WITH hidden AS (
+            UPDATE coupons c SET
+                moderation_status = 'hidden',
+                moderation_reason = NULLIF($2, ''),
+                moderated_at = CURRENT_TIMESTAMP
+            FROM (
+                SELECT id, moderation_status FROM coupons
+                WHERE merchant_domain = normalize_merchant_domain($1)
+                  AND moderation_status IN ('pending', 'approved')
+                FOR UPDATE
+            ) AS previous
+            WHERE c.id = previous.id
+            RETURNING c.id, c.merchant_name, previous.moderation_status
+        ), logged AS (
+            INSERT INTO moderation_log (coupon_id, previous_status, status, reason, api_key_id)
+            SELECT id, moderation_status, 'hidden', NULLIF($2, ''), $3::bigint FROM hidden
+        )
+        SELECT id, merchant_name FROM hidden ORDER BY id`,
+		merchantDomain, reason, apiKeyID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	var merchantNames []string
+	for rows.Next() {
+		var id int64
+		var merchantName string
+		if err := rows.Scan(&id, &merchantName); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		merchantNames = append(merchantNames, merchantName)
+	}
+	return ids, merchantNames, rows.Err()
+}
+
+// Log returns a page of the moderation log, newest first. If couponID is not 0, only the entries of that coupon.
+func (r *ModerationRepository) Log(ctx context.Context, couponID int64, limit, offset int) ([]models.ModerationLogEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, coupon_id, previous_status, status, COALESCE(reason, ''), api_key_id, created_at
+        FROM moderation_log
+        WHERE $1::bigint = 0 OR coupon_id = $1
+        ORDER BY id DESC
+        LIMIT $2 OFFSET $3`,
+		couponID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.ModerationLogEntry{}
+	for rows.Next() {
+		var entry models.ModerationLogEntry
+		err := rows.Scan(
+			&entry.ID, &entry.CouponID, &entry.PreviousStatus, &entry.Status,
+			&entry.Reason, &entry.APIKeyID, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/models"
+	"errors"
+)
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Create stores a report about an approved coupon and returns the number of reports made since the coupon
+// was last moderated, so reports already dealt with by a moderator do not count again.
+// Reporting the same coupon again replaces the reporter's previous report.
+// Returns ErrCouponNotFound if the coupon does not exist or is not approved.
+func (r *ReportRepository) Create(ctx context.Context, report models.Report) (int64, error) {
+	var couponID int64
+	err := r.db.QueryRowContext(ctx, `
+        INSERT INTO coupon_reports (coupon_id, reason, comment, reporter_hash)
+        SELECT id, $2, NULLIF($3, ''), $4 FROM coupons WHERE id = $1 AND moderation_status = 'approved'
+        ON CONFLICT (coupon_id, reporter_hash) DO UPDATE SET
+            reason = EXCLUDED.reason,
+            comment = EXCLUDED.comment,
+            created_at = CURRENT_TIMESTAMP
+        RETURNING coupon_id`,
+		report.CouponID, report.Reason, report.Comment, report.ReporterHash,
+	).Scan(&couponID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrCouponNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	err = r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM coupon_reports r
+        JOIN coupons c ON c.id = r.coupon_id
+        WHERE r.coupon_id = $1 AND r.created_at > COALESCE(c.moderated_at, '-infinity')`,
+		couponID,
+	).Scan(&total)
+	return total, err
+}
+
+// Stats returns a page of the reported coupons, most reported first
+func (r *ReportRepository) Stats(ctx context.Context, limit, offset int) ([]models.CouponReportStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT
+            coupon_id,
+            COUNT(*),
+            COUNT(*) FILTER (WHERE reason = 'expired'),
+            COUNT(*) FILTER (WHERE reason = 'wrong_merchant'),
+            COUNT(*) FILTER (WHERE reason = 'spam'),
+            COUNT(*) FILTER (WHERE reason = 'offensive'),
+            MAX(created_at)
+        FROM coupon_reports
+        GROUP BY coupon_id
+        ORDER BY COUNT(*) DESC, MAX(created_at) DESC, coupon_id
+        LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.CouponReportStats{}
+	for rows.Next() {
+		var s models.CouponReportStats
+		err := rows.Scan(&s.CouponID, &s.Total, &s.Expired, &s.WrongMerchant, &s.Spam, &s.Offensive, &s.LastReportedAt)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"discountdb-api/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type ScoreRepository struct {
+	db *sql.DB
+}
+
+func NewScoreRepository(db *sql.DB) *ScoreRepository {
+	return &ScoreRepository{db: db}
+}
+
+const scoreSettingsColumns = `
+            version, vote_model, wilson_z, prior_mean, prior_weight,
+            vote_weight, discount_weight, freshness_weight,
+            vote_decay, vote_decay_floor, freshness_decay, freshness_decay_floor,
+            fixed_amount_reference, flat_discount_score, COALESCE(comment, ''), created_at`
+
+func scanScoreSettings(row rowScanner) (*models.ScoreSettings, error) {
+	settings := &models.ScoreSettings{}
+	var voteDecay, freshnessDecay []byte
+	err := row.Scan(
+		&settings.Version, &settings.VoteModel, &settings.WilsonZ, &settings.PriorMean, &settings.PriorWeight,
+		&settings.VoteWeight, &settings.DiscountWeight, &settings.FreshnessWeight,
+		&voteDecay, &settings.VoteDecayFloor, &freshnessDecay, &settings.FreshnessDecayFloor,
+		&settings.FixedAmountReference, &settings.FlatDiscountScore, &settings.Comment, &settings.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(voteDecay, &settings.VoteDecay); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(freshnessDecay, &settings.FreshnessDecay); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ListSettings returns a page of the score settings versions, newest first. The first one is in use.
+func (r *ScoreRepository) ListSettings(ctx context.Context, limit, offset int) ([]models.ScoreSettings, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT`+scoreSettingsColumns+`
+        FROM score_settings
+        ORDER BY version DESC
+        LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.ScoreSettings{}
+	for rows.Next() {
+		settings, err := scanScoreSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *settings)
+	}
+	return versions, rows.Err()
+}
+
+// CreateSettings stores a new version of the score settings, which is used from now on.
+// All scores are marked as due, so the score updater recomputes them with the new settings.
+func (r *ScoreRepository) CreateSettings(ctx context.Context, request models.ScoreSettingsRequest, createdBy *int64) (settings *models.ScoreSettings, err error) {
+	voteDecay, err := json.Marshal(request.VoteDecay)
+	if err != nil {
+		return nil, err
+	}
+	freshnessDecay, err := json.Marshal(request.FreshnessDecay)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	settings, err = scanScoreSettings(tx.QueryRowContext(ctx, `
+        INSERT INTO score_settings (
+            vote_model, wilson_z, prior_mean, prior_weight,
+            vote_weight, discount_weight, freshness_weight,
+            vote_decay, vote_decay_floor, freshness_decay, freshness_decay_floor,
+            fixed_amount_reference, flat_discount_score, comment, created_by
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15)
+        RETURNING`+scoreSettingsColumns,
+		request.VoteModel, request.WilsonZ, request.PriorMean, request.PriorWeight,
+		request.VoteWeight, request.DiscountWeight, request.FreshnessWeight,
+		string(voteDecay), request.VoteDecayFloor, string(freshnessDecay), request.FreshnessDecayFloor,
+		request.FixedAmountReference, request.FlatDiscountScore, request.Comment, createdBy,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE coupons SET next_score_update = CURRENT_TIMESTAMP`); err != nil {
+		return nil, err
+	}
+
+	return settings, tx.Commit()
+}
+
+// Explain breaks the score of an approved coupon down into its parts under the current score settings.
+// Returns ErrCouponNotFound if the coupon does not exist or is not approved.
+func (r *ScoreRepository) Explain(ctx context.Context, couponID int64) (*models.ScoreExplanation, error) {
+	e := &models.ScoreExplanation{CouponID: couponID}
+	err := r.db.QueryRowContext(ctx, `
+        SELECT
+            COALESCE(c.materialized_score, 0), c.last_score_update, s.settings_version, ss.vote_model,
+            s.vote_score, s.vote_weight,
+            COALESCE(s.discount_score, 0), s.discount_weight,
+            s.freshness_score, s.freshness_weight
+        FROM coupons c
+        CROSS JOIN LATERAL coupon_score_components(
+            c.id, c.discount_value, c.discount_type, c.maximum_discount_amount, c.created_at
+        ) s
+        JOIN score_settings ss ON ss.version = s.settings_version
+        WHERE c.id = $1 AND c.moderation_status = 'approved'`,
+		couponID,
+	).Scan(
+		&e.Score, &e.LastScoreUpdate, &e.SettingsVersion, &e.VoteModel,
+		&e.Vote.Value, &e.Vote.Weight,
+		&e.Discount.Value, &e.Discount.Weight,
+		&e.Freshness.Value, &e.Freshness.Weight,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, component := range []*models.ScoreComponent{&e.Vote, &e.Discount, &e.Freshness} {
+		component.Contribution = component.Value * component.Weight
+		e.Total += component.Contribution
+	}
+	return e, nil
+}
+
+// RescoredCoupon is a coupon whose score was recomputed, its cache entries are outdated
+type RescoredCoupon struct {
+	ID           int64
+	MerchantName string
+}
+
+func scanRescoredCoupons(rows *sql.Rows) ([]RescoredCoupon, error) {
+	defer rows.Close()
+
+	coupons := []RescoredCoupon{}
+	for rows.Next() {
+		var coupon RescoredCoupon
+		if err := rows.Scan(&coupon.ID, &coupon.MerchantName); err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, rows.Err()
+}
+
+// UpdateDueBatch recomputes up to limit scores that are due to change and returns the coupons,
+// fewer than limit once no more are due
+func (r *ScoreRepository) UpdateDueBatch(ctx context.Context, limit int) ([]RescoredCoupon, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, merchant_name FROM update_due_scores_batch($1)`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanRescoredCoupons(rows)
+}
+
+// CountNotDue returns the number of coupons whose scores are not due to change yet
+func (r *ScoreRepository) CountNotDue(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM coupons
+        WHERE next_score_update IS NULL OR next_score_update > CURRENT_TIMESTAMP`,
+	).Scan(&count)
+	return count, err
+}
+
+// RecomputeBatch recomputes the scores of up to limit coupons with an id above afterID and returns the coupons,
+// none once all are done
+func (r *ScoreRepository) RecomputeBatch(ctx context.Context, afterID int64, limit int) ([]RescoredCoupon, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        UPDATE coupons c
+        SET materialized_score = calculate_coupon_score(
+                c.id,
+                c.discount_value,
+                c.discount_type,
+                c.maximum_discount_amount,
+                c.created_at
+            ),
+            last_score_update = CURRENT_TIMESTAMP,
+            next_score_update = next_score_change(c.id, c.created_at)
+        WHERE c.id IN (SELECT id FROM coupons WHERE id > $1 ORDER BY id LIMIT $2)
+        RETURNING c.id, c.merchant_name`,
+		afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanRescoredCoupons(rows)
+}
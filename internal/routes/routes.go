@@ -1,57 +1,88 @@
 package routes
 
 import (
-	"context"
 	"database/sql"
+	"discountdb-api/internal/cache"
+	"discountdb-api/internal/config"
 	"discountdb-api/internal/handlers"
+	"discountdb-api/internal/handlers/admin"
 	"discountdb-api/internal/handlers/coupons"
 	"discountdb-api/internal/handlers/syrup"
+	"discountdb-api/internal/jobs"
 	"discountdb-api/internal/middleware"
+	"discountdb-api/internal/models"
 	"discountdb-api/internal/repositories"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
-	"log"
 	"time"
 )
 
-func SetupRoutes(app *fiber.App, db *sql.DB, rdb *redis.Client) {
-	ctx := context.Background()
+func SetupRoutes(app *fiber.App, cfg *config.Config, db *sql.DB, rdb *redis.Client, store *cache.Store, scheduler *jobs.Scheduler) {
 	api := app.Group("/api/v1")
 
-	// Middlewares
+	// Bulk imports read the streamed body with their own limit, see coupons.PostCouponsBulk
+	app.Use(middleware.NewBodyLimit(func(c *fiber.Ctx) bool {
+		return c.Method() == fiber.MethodPost && c.Path() == "/api/v1/coupons/bulk"
+	}))
+
+	// Middlewares, all rate limiters share one circuit breaker as they share one Redis
+	rateLimitBreaker := middleware.NewCircuitBreaker("Rate limiter Redis", 5, 30*time.Second)
+
 	defaultRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
-		Max:       100,
-		Window:    time.Minute,
-		Redis:     rdb,
-		KeyPrefix: "ratelimit:",
+		Max:           100,
+		Name:          "default",
+		Window:        time.Minute,
+		Redis:         rdb,
+		FailurePolicy: cfg.RateLimitFailurePolicy,
+		Breaker:       rateLimitBreaker,
+		KeyPrefix:     "ratelimit:",
 	})
 
-	singleVoteRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
-		Max:       1,
-		Window:    10 * time.Minute,
-		Redis:     rdb,
-		KeyPrefix: "singlevotelimit:",
+	voteRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
+		Max:           10,
+		Name:          "vote",
+		Window:        10 * time.Minute,
+		Redis:         rdb,
+		FailurePolicy: cfg.RateLimitFailurePolicy,
+		Breaker:       rateLimitBreaker,
+		KeyPrefix:     "votelimit:",
 		KeyFunc: func(c *fiber.Ctx) string {
-			return fmt.Sprintf("%s:%s", c.IP(), c.Params("id"))
+			return fmt.Sprintf("%s:%s", middleware.ClientID(c), c.Params("id"))
 		},
 	})
 
-	voteRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
-		Max:       10,
-		Window:    10 * time.Minute,
-		Redis:     rdb,
-		KeyPrefix: "votelimit:",
+	createCouponRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
+		Max:           2,
+		Name:          "create",
+		Window:        10 * time.Minute,
+		Redis:         rdb,
+		FailurePolicy: cfg.RateLimitFailurePolicy,
+		Breaker:       rateLimitBreaker,
+		KeyPrefix:     "createcouponlimit:",
+	})
+
+	reportRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
+		Max:           10,
+		Name:          "report",
+		Window:        10 * time.Minute,
+		Redis:         rdb,
+		FailurePolicy: cfg.RateLimitFailurePolicy,
+		Breaker:       rateLimitBreaker,
+		KeyPrefix:     "reportlimit:",
 		KeyFunc: func(c *fiber.Ctx) string {
-			return fmt.Sprintf("%s:%s", c.IP(), c.Params("id"))
+			return fmt.Sprintf("%s:%s", middleware.ClientID(c), c.Params("id"))
 		},
 	})
 
-	createCouponRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
-		Max:       2,
-		Window:    10 * time.Minute,
-		Redis:     rdb,
-		KeyPrefix: "createcouponlimit:",
+	exportRateLimiter := middleware.NewRateLimiter(middleware.RateLimiterConfig{
+		Max:           10,
+		Name:          "export",
+		Window:        time.Hour,
+		Redis:         rdb,
+		FailurePolicy: cfg.RateLimitFailurePolicy,
+		Breaker:       rateLimitBreaker,
+		KeyPrefix:     "exportlimit:",
 	})
 
 	// Default route
@@ -59,60 +90,122 @@ func SetupRoutes(app *fiber.App, db *sql.DB, rdb *redis.Client) {
 		return c.SendString("API is running") // Or redirect to docs/API info
 	})
 
+	// Requests may identify themselves with an API key, which grants scopes and its own rate limits
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	api.Use(middleware.NewAPIKeyAuth(apiKeyRepo, store))
+
+	readScope := middleware.CheckScope(models.ScopeRead)
+	submitScope := middleware.CheckScope(models.ScopeSubmit)
+	voteScope := middleware.CheckScope(models.ScopeVote)
+
 	// Health check endpoint
-	api.Get("/health", handlers.HealthCheck)
+	api.Get("/health", func(ctx *fiber.Ctx) error {
+		return handlers.HealthCheck(ctx, rateLimitBreaker, cfg.RateLimitFailurePolicy)
+	})
 
 	// Coupon endpoints
 	couponRepo := repositories.NewCouponRepository(db)
-	if err := couponRepo.CreateTable(ctx); err != nil {
-		log.Fatalf("Failed to create coupon table: %v", err)
+	moderationRepo := repositories.NewModerationRepository(db)
+	reportRepo := repositories.NewReportRepository(db)
+	scoreRepo := repositories.NewScoreRepository(db)
+
+	reportPolicy := coupons.ReportPolicy{Threshold: cfg.ReportThreshold, Status: models.ModerationPending}
+	if cfg.ReportAction == "hide" {
+		reportPolicy.Status = models.ModerationHidden
 	}
 
-	api.Post("/coupons", createCouponRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.PostCoupon(ctx, couponRepo, rdb)
+	api.Post("/coupons", submitScope, createCouponRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.PostCoupon(ctx, couponRepo, store)
+	})
+	api.Post("/coupons/bulk", middleware.RequireScope(models.ScopeBulk), func(ctx *fiber.Ctx) error {
+		return coupons.PostCouponsBulk(ctx, couponRepo, store)
+	})
+	api.Get("/coupons/search", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetCoupons(ctx, couponRepo, store)
+	})
+	api.Get("/coupons/export", readScope, exportRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetCouponsExport(ctx, couponRepo)
 	})
-	api.Get("/coupons/search", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.GetCoupons(ctx, couponRepo, rdb)
+	api.Get("/coupons/merchants", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetMerchants(ctx, couponRepo, store)
 	})
-	api.Get("/coupons/merchants", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.GetMerchants(ctx, couponRepo, rdb)
+	api.Get("/coupons/categories", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetCategories(ctx, couponRepo, store)
 	})
-	api.Get("/coupons/categories", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.GetCategories(ctx, couponRepo, rdb)
+	api.Get("/coupons/tags", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetTags(ctx, couponRepo, store)
 	})
-	api.Get("/coupons/tags", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.GetTags(ctx, couponRepo, rdb)
+	api.Get("/coupons/regions", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetRegions(ctx, couponRepo, store)
 	})
-	api.Get("/coupons/regions", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.GetRegions(ctx, couponRepo, rdb)
+	api.Post("/coupons/vote/:dir/:id", voteScope, voteRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.PostVote(ctx, rdb, cfg.VoterHashSalt)
 	})
-	api.Post("/coupons/vote/:dir/:id", voteRateLimiter, singleVoteRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.PostVote(ctx, rdb)
+	api.Delete("/coupons/vote/:id", voteScope, voteRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.DeleteVote(ctx, rdb, cfg.VoterHashSalt)
+	})
+	api.Post("/coupons/:id/report", voteScope, reportRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.PostReport(ctx, reportRepo, moderationRepo, store, cfg.VoterHashSalt, reportPolicy)
+	})
+	api.Get("/coupons/:id/score", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetCouponScore(ctx, scoreRepo)
 	})
 	// This has to be the last route to avoid conflicts
-	api.Get("/coupons/:id", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return coupons.GetCouponByID(ctx, couponRepo, rdb)
+	api.Get("/coupons/:id", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.GetCouponByID(ctx, couponRepo, store)
+	})
+	api.Patch("/coupons/:id", submitScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.PatchCoupon(ctx, couponRepo, store)
+	})
+	api.Delete("/coupons/:id", submitScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return coupons.DeleteCoupon(ctx, couponRepo, store)
 	})
 
-	// Start processing vote queue
-	go func() {
-		if err := coupons.ProcessVoteQueue(context.Background(), couponRepo, rdb, 100); err != nil {
-			log.Printf("Vote processor error: %v", err)
-		}
-	}()
+	// Moderation endpoints
+	adminAPI := api.Group("/admin", middleware.RequireScope(models.ScopeAdmin))
+	adminAPI.Get("/moderation/queue", func(ctx *fiber.Ctx) error {
+		return admin.GetModerationQueue(ctx, moderationRepo)
+	})
+	adminAPI.Get("/moderation/log", func(ctx *fiber.Ctx) error {
+		return admin.GetModerationLog(ctx, moderationRepo)
+	})
+	adminAPI.Get("/reports", func(ctx *fiber.Ctx) error {
+		return admin.GetReports(ctx, reportRepo)
+	})
+	adminAPI.Post("/coupons/:id/approve", func(ctx *fiber.Ctx) error {
+		return admin.PostApproveCoupon(ctx, moderationRepo, store)
+	})
+	adminAPI.Post("/coupons/:id/reject", func(ctx *fiber.Ctx) error {
+		return admin.PostRejectCoupon(ctx, moderationRepo, store)
+	})
+	adminAPI.Post("/merchants/hide", func(ctx *fiber.Ctx) error {
+		return admin.PostHideMerchant(ctx, moderationRepo, store)
+	})
+	adminAPI.Get("/score-settings", func(ctx *fiber.Ctx) error {
+		return admin.GetScoreSettings(ctx, scoreRepo)
+	})
+	adminAPI.Post("/score-settings", func(ctx *fiber.Ctx) error {
+		return admin.PostScoreSettings(ctx, scoreRepo)
+	})
+	adminAPI.Get("/jobs", func(ctx *fiber.Ctx) error {
+		return admin.GetJobs(ctx, scheduler)
+	})
+	adminAPI.Post("/jobs/:name/run", func(ctx *fiber.Ctx) error {
+		return admin.PostRunJob(ctx, scheduler)
+	})
 
 	// Syrup Endpoint
 	api.Get("/syrup/version", syrup.GetVersionInfo)
-	api.Get("/syrup/coupons", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return syrup.GetCoupons(ctx, couponRepo, rdb)
+	api.Get("/syrup/coupons", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return syrup.GetCoupons(ctx, couponRepo, store)
 	})
-	api.Post("/syrup/coupons/valid/:id", voteRateLimiter, singleVoteRateLimiter, func(ctx *fiber.Ctx) error {
-		return syrup.PostCouponValid(ctx, rdb)
+	api.Post("/syrup/coupons/valid/:id", voteScope, voteRateLimiter, func(ctx *fiber.Ctx) error {
+		return syrup.PostCouponValid(ctx, rdb, cfg.VoterHashSalt)
 	})
-	api.Post("/syrup/coupons/invalid/:id", voteRateLimiter, singleVoteRateLimiter, func(ctx *fiber.Ctx) error {
-		return syrup.PostCouponInvalid(ctx, rdb)
+	api.Post("/syrup/coupons/invalid/:id", voteScope, voteRateLimiter, func(ctx *fiber.Ctx) error {
+		return syrup.PostCouponInvalid(ctx, rdb, cfg.VoterHashSalt)
 	})
-	api.Get("/syrup/merchants", defaultRateLimiter, func(ctx *fiber.Ctx) error {
-		return syrup.GetMerchants(ctx, couponRepo, rdb)
+	api.Get("/syrup/merchants", readScope, defaultRateLimiter, func(ctx *fiber.Ctx) error {
+		return syrup.GetMerchants(ctx, couponRepo, store)
 	})
 }